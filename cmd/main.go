@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/pborman/uuid"
 	swaggerFiles "github.com/swaggo/files"
@@ -16,6 +23,8 @@ import (
 	_ "github.com/youssef1337/wikipedia-api/docs"
 
 	"github.com/youssef1337/wikipedia-api/internal"
+	"github.com/youssef1337/wikipedia-api/internal/config"
+	"github.com/youssef1337/wikipedia-api/internal/tracing"
 )
 
 // @title Wikipedia API
@@ -30,11 +39,42 @@ import (
 
 // @schemes https http
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
 // @license.name Apache 2.0
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 func main() {
+	cfg, opts, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.PrintConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	internal.Setup(cfg)
+
+	shutdownTracing, err := tracing.Setup(cfg, os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	r := gin.New()
+	if err := internal.TrustProxies(r); err != nil {
+		log.Fatal(err)
+	}
 
 	r.Use(func(c *gin.Context) {
 		reqID := uuid.New()
@@ -43,37 +83,55 @@ func main() {
 		c.Next()
 	})
 
-	r.Use(gin.Logger())
+	r.Use(internal.Trace())
+	r.Use(internal.Instrument())
+	r.Use(internal.Logger())
 	r.Use(gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		log.Printf("PANIC: %v", recovered)
 		internal.InternalServerErrorHandler(c, fmt.Errorf("%v", recovered))
 	}))
 
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://wikipedia.youssefsobhy.com"}
-	config.AllowMethods = []string{"GET"}
+	r.Use(internal.CORS())
 
-	r.Use(cors.New(config))
-
-	v1 := r.Group("/api/v1")
+	v1 := r.Group("/api/v1", internal.Authenticate(), internal.RateLimiter(), internal.Authorize())
 	{
 		v1.GET("", internal.Health)
 		v1.GET("/search", internal.Search)
+		v1.POST("/search/batch", internal.SearchBatch)
+		v1.GET("/summary", internal.Summary)
+		v1.GET("/suggest", internal.Suggest)
 		v1.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 		v1.GET("/docs", func(c *gin.Context) {
 			c.Redirect(http.StatusMovedPermanently, "/api/v1/docs/index.html")
 		})
 	}
 
+	r.GET("/metrics", internal.Metrics())
+	r.GET("/livez", internal.Livez)
+	r.GET("/readyz", internal.Readyz)
+
 	r.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/api/v1/docs/index.html")
 	})
 
-	port := os.Getenv("PORT")
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.Port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if port == "" {
-		port = "3000"
+	log.Printf("Listening on %s", ln.Addr())
+	if err := internal.Serve(ctx, internal.NewServer(r), ln); err != nil {
+		log.Fatal(err)
 	}
 
-	r.Run(":" + port)
+	// Spans still waiting in the batcher are sent before exiting.
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("Failed to flush spans: %s", err)
+	}
 }
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jarcoal/httpmock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/youssef1337/wikipedia-api/internal"
+	"github.com/youssef1337/wikipedia-api/internal/config"
+	"github.com/youssef1337/wikipedia-api/internal/tracing"
 )
 
 var _ = BeforeSuite(func() {
@@ -27,6 +35,9 @@ var _ = Describe("WikipediaApi", func() {
 	BeforeEach(func() {
 		// remove any mocks
 		httpmock.Reset()
+
+		// start every test with an empty cache
+		internal.Setup(config.Default())
 	})
 
 	Describe("/health", func() {
@@ -43,6 +54,665 @@ var _ = Describe("WikipediaApi", func() {
 
 				Expect(w.Code).To(Equal(http.StatusOK))
 				Expect(response["status"]).To(Equal("operational"))
+				Expect(response["circuit_breaker"]).To(Equal("closed"))
+			})
+		})
+	})
+
+	Describe("/livez", func() {
+		It("should return 200 without calling the wikipedia API", func() {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			internal.Livez(c)
+			var response internal.LivenessResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(response.Status).To(Equal("alive"))
+			Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("/readyz", func() {
+		const siteInfoQuery = "action=query&meta=siteinfo&siprop=general&formatversion=2&format=json&maxlag=5"
+
+		readyz := func() (int, internal.ReadinessResponse) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			internal.Readyz(c)
+			var response internal.ReadinessResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			return w.Code, response
+		}
+
+		Context("when the wikipedia API is reachable", func() {
+			It("should return 200 with every check and reuse the upstream check", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					siteInfoQuery,
+					httpmock.NewStringResponder(
+						200,
+						`{"batchcomplete":true,"query":{"general":{"sitename":"Wikipedia","generator":"MediaWiki 1.41.0-wmf.1"}}}`,
+					),
+				)
+
+				code, response := readyz()
+
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Status).To(Equal("ready"))
+				Expect(response.Checks).To(HaveLen(5))
+				for name, check := range response.Checks {
+					Expect(check.Status).To(Equal("pass"), name)
+				}
+				Expect(response.Checks["upstream"].Message).To(Equal("MediaWiki 1.41.0-wmf.1"))
+				Expect(response.Checks["upstream"].Cached).To(BeFalse())
+				Expect(response.Checks["cache"].Message).To(Equal("0 of 10000 entries"))
+				Expect(response.Checks["circuit_breaker"].Message).To(Equal("closed"))
+
+				code, response = readyz()
+
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Checks["upstream"].Cached).To(BeTrue())
+				Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the wikipedia API is unavailable", func() {
+			It("should stay ready and warn about the upstream check", func() {
+				cfg := config.Default()
+				cfg.RetryMaxAttempts = 1
+				internal.Setup(cfg)
+
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					siteInfoQuery,
+					httpmock.NewStringResponder(503, ""),
+				)
+
+				code, response := readyz()
+
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Status).To(Equal("ready"))
+				Expect(response.Checks["upstream"].Status).To(Equal("warn"))
+				Expect(response.Checks["upstream"].Message).To(ContainSubstring("503"))
+				Expect(response.Checks["server"].Status).To(Equal("pass"))
+			})
+		})
+
+		Context("when lookups hold every upstream slot", func() {
+			It("should probe the wikipedia API without waiting for a slot", func() {
+				cfg := config.Default()
+				cfg.UpstreamMaxConcurrency = 1
+				internal.Setup(cfg)
+
+				started := make(chan struct{})
+				release := make(chan struct{})
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					func(req *http.Request) (*http.Response, error) {
+						close(started)
+						<-release
+
+						return httpmock.NewStringResponse(200, `{"query": {"pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`), nil
+					},
+				)
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					siteInfoQuery,
+					httpmock.NewStringResponder(
+						200,
+						`{"batchcomplete":true,"query":{"general":{"sitename":"Wikipedia","generator":"MediaWiki 1.41.0-wmf.1"}}}`,
+					),
+				)
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio", nil)
+					c, _ := gin.CreateTestContext(httptest.NewRecorder())
+					c.Request = req
+					internal.Search(c)
+				}()
+
+				<-started
+				code, response := readyz()
+				close(release)
+				<-done
+
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Checks["upstream"].Status).To(Equal("pass"))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when the circuit breaker is open", func() {
+			It("should stay ready and warn about the circuit breaker", func() {
+				cfg := config.Default()
+				cfg.RetryMaxAttempts = 1
+				cfg.BreakerFailureThreshold = 1
+				internal.Setup(cfg)
+
+				httpmock.RegisterNoResponder(httpmock.NewStringResponder(503, ""))
+
+				req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio", nil)
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				c.Request = req
+				internal.Search(c)
+
+				code, response := readyz()
+
+				Expect(code).To(Equal(http.StatusOK))
+				Expect(response.Status).To(Equal("ready"))
+				Expect(response.Checks["circuit_breaker"].Status).To(Equal("warn"))
+				Expect(response.Checks["circuit_breaker"].Message).To(Equal("open"))
+			})
+		})
+	})
+
+	Describe("CORS", func() {
+		Context("when origins are configured with a wildcard and a route overrides the methods", func() {
+			It("should answer preflight requests with the policy of the route", func() {
+				cfg := config.Default()
+				cfg.CORS.AllowOrigins = []string{"https://*.example.com"}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				r.Use(internal.CORS())
+				r.GET("/api/v1/search", internal.Search)
+				r.POST("/api/v1/search/batch", internal.SearchBatch)
+
+				preflight := func(url, origin, method string) *httptest.ResponseRecorder {
+					req, _ := http.NewRequest("OPTIONS", url, nil)
+					req.Header.Set("Origin", origin)
+					req.Header.Set("Access-Control-Request-Method", method)
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w
+				}
+
+				w := preflight("/api/v1/search/batch", "https://app.example.com", "POST")
+				Expect(w.Code).To(Equal(http.StatusNoContent))
+				Expect(w.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+				Expect(w.Header().Get("Access-Control-Allow-Methods")).To(Equal("POST"))
+				Expect(w.Header().Get("Access-Control-Max-Age")).To(Equal("43200"))
+
+				w = preflight("/api/v1/search", "https://app.example.com", "GET")
+				Expect(w.Code).To(Equal(http.StatusNoContent))
+				Expect(w.Header().Get("Access-Control-Allow-Methods")).To(Equal("GET"))
+
+				w = preflight("/api/v1/search", "https://example.org", "GET")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+	})
+
+	Describe("authentication", func() {
+		Context("when API keys are configured", func() {
+			It("should require a valid key that may access the route and has quota left", func() {
+				cfg := config.Default()
+				cfg.APIKeys = []config.APIKey{{
+					Name: "search-team",
+					// sha256 of "s3cret"
+					SHA256:     "1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+					Routes:     []string{"/api/v1/search"},
+					DailyQuota: 1,
+				}}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				r.Use(internal.Authenticate(), internal.Authorize())
+				r.GET("/api/v1", internal.Health)
+				r.GET("/api/v1/search", func(c *gin.Context) {
+					c.String(http.StatusOK, c.GetString(internal.APIKeyContextKey))
+				})
+				r.GET("/api/v1/suggest", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				request := func(url, apiKey string) *httptest.ResponseRecorder {
+					req, _ := http.NewRequest("GET", url, nil)
+					if apiKey != "" {
+						req.Header.Set("X-API-Key", apiKey)
+					}
+
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w
+				}
+
+				detail := func(w *httptest.ResponseRecorder) string {
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					return response.Errors[0].Detail
+				}
+
+				Expect(request("/api/v1", "").Code).To(Equal(http.StatusOK))
+
+				w := request("/api/v1/search", "")
+				Expect(w.Code).To(Equal(http.StatusUnauthorized))
+				Expect(detail(w)).To(Equal("An API key is required. Please pass it in the X-API-Key header or the api_key query parameter."))
+
+				w = request("/api/v1/search", "guess")
+				Expect(w.Code).To(Equal(http.StatusUnauthorized))
+				Expect(detail(w)).To(Equal("Invalid API key."))
+
+				w = request("/api/v1/suggest", "s3cret")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+				Expect(detail(w)).To(Equal("The API key 'search-team' is not allowed to access /api/v1/suggest."))
+
+				w = request("/api/v1/search?api_key=s3cret", "")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(Equal("search-team"))
+
+				w = request("/api/v1/search", "s3cret")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+				Expect(detail(w)).To(Equal("The API key 'search-team' has used up its daily quota of 1 requests. The quota resets at midnight UTC."))
+			})
+		})
+
+		Context("when requests are rate limited", func() {
+			It("should limit requests without a valid key by IP and not count rejected requests against the quota", func() {
+				cfg := config.Default()
+				cfg.APIKeys = []config.APIKey{{
+					Name: "search-team",
+					// sha256 of "s3cret"
+					SHA256:     "1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+					DailyQuota: 2,
+				}}
+				cfg.RateLimit = config.RateLimit{Requests: 1, Period: time.Minute}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				api := r.Group("/api/v1", internal.Authenticate(), internal.RateLimiter(), internal.Authorize())
+				for _, route := range []string{"/search", "/summary", "/suggest"} {
+					api.GET(route, func(c *gin.Context) {
+						c.Status(http.StatusOK)
+					})
+				}
+
+				request := func(url, apiKey string) int {
+					req, _ := http.NewRequest("GET", url, nil)
+					req.RemoteAddr = "192.0.2.1:1234"
+					if apiKey != "" {
+						req.Header.Set("X-API-Key", apiKey)
+					}
+
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w.Code
+				}
+
+				Expect(request("/api/v1/search", "guess")).To(Equal(http.StatusUnauthorized))
+				Expect(request("/api/v1/search", "")).To(Equal(http.StatusTooManyRequests))
+
+				Expect(request("/api/v1/search", "s3cret")).To(Equal(http.StatusOK))
+				Expect(request("/api/v1/search", "s3cret")).To(Equal(http.StatusTooManyRequests))
+				Expect(request("/api/v1/summary", "s3cret")).To(Equal(http.StatusOK))
+				Expect(request("/api/v1/suggest", "s3cret")).To(Equal(http.StatusForbidden))
+			})
+		})
+
+		Context("when the API key is passed as a query parameter", func() {
+			It("should not write it to the request log", func() {
+				var logs bytes.Buffer
+				defaultWriter := gin.DefaultWriter
+				gin.DefaultWriter = &logs
+				defer func() { gin.DefaultWriter = defaultWriter }()
+
+				r := gin.New()
+				r.Use(internal.Logger())
+				r.GET("/api/v1/search", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio&api_key=s3cret", nil)
+				r.ServeHTTP(httptest.NewRecorder(), req)
+
+				Expect(logs.String()).To(ContainSubstring(`"/api/v1/search?query=Yoshua_Bengio&api_key=REDACTED"`))
+				Expect(logs.String()).NotTo(ContainSubstring("s3cret"))
+			})
+		})
+
+		Context("when a request is authenticated", func() {
+			It("should write the name of its API key to the request log", func() {
+				var logs bytes.Buffer
+				defaultWriter := gin.DefaultWriter
+				gin.DefaultWriter = &logs
+				defer func() { gin.DefaultWriter = defaultWriter }()
+
+				cfg := config.Default()
+				cfg.APIKeys = []config.APIKey{{
+					Name: "search-team",
+					// sha256 of "s3cret"
+					SHA256: "1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+				}}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				r.Use(internal.Logger(), internal.Authenticate(), internal.Authorize())
+				r.GET("/api/v1/search", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				for _, apiKey := range []string{"s3cret", ""} {
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio", nil)
+					if apiKey != "" {
+						req.Header.Set("X-API-Key", apiKey)
+					}
+					r.ServeHTTP(httptest.NewRecorder(), req)
+				}
+
+				var lines []string
+				for _, line := range strings.Split(logs.String(), "\n") {
+					if strings.HasPrefix(line, "[GIN] ") {
+						lines = append(lines, line)
+					}
+				}
+
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(MatchRegexp(`\| 200 \|.*\| search-team \| GET .*"/api/v1/search\?query=Yoshua_Bengio"`))
+				Expect(lines[1]).To(MatchRegexp(`\| 401 \|.*\| - \| GET `))
+			})
+		})
+	})
+
+	Describe("rate limiting", func() {
+		Context("when a client exceeds the rate limit", func() {
+			It("should return 429 with the rate limit headers, without limiting other clients", func() {
+				cfg := config.Default()
+				cfg.RateLimit = config.RateLimit{Requests: 2, Period: time.Minute}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				r.GET("/api/v1", internal.RateLimiter(), internal.Health)
+
+				request := func(remoteAddr string) *httptest.ResponseRecorder {
+					req, _ := http.NewRequest("GET", "/api/v1", nil)
+					req.RemoteAddr = remoteAddr
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w
+				}
+
+				w := request("192.0.2.1:1234")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("RateLimit-Limit")).To(Equal("2"))
+				Expect(w.Header().Get("RateLimit-Remaining")).To(Equal("1"))
+				Expect(w.Header().Get("RateLimit-Reset")).To(Equal("30"))
+
+				Expect(request("192.0.2.1:1234").Code).To(Equal(http.StatusOK))
+
+				w = request("192.0.2.1:1234")
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(w.Header().Get("RateLimit-Remaining")).To(Equal("0"))
+				Expect(w.Header().Get("Retry-After")).To(Equal("30"))
+				Expect(response.Errors[0].Detail).To(Equal("Too many requests. Please try again in 30 seconds."))
+
+				Expect(request("192.0.2.2:1234").Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		Context("when a client names another address in the X-Forwarded-For header", func() {
+			It("should only believe the header from a trusted proxy", func() {
+				cfg := config.Default()
+				cfg.RateLimit = config.RateLimit{Requests: 1, Period: time.Minute}
+				cfg.TrustedProxies = []string{"10.0.0.0/8"}
+				internal.Setup(cfg)
+
+				r := gin.New()
+				Expect(internal.TrustProxies(r)).To(Succeed())
+				r.GET("/api/v1", internal.RateLimiter(), internal.Health)
+
+				request := func(remoteAddr, forwardedFor string) int {
+					req, _ := http.NewRequest("GET", "/api/v1", nil)
+					req.RemoteAddr = remoteAddr
+					req.Header.Set("X-Forwarded-For", forwardedFor)
+					req.Header.Set("X-Real-IP", forwardedFor)
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w.Code
+				}
+
+				Expect(request("192.0.2.1:1234", "198.51.100.1")).To(Equal(http.StatusOK))
+				Expect(request("192.0.2.1:1234", "198.51.100.2")).To(Equal(http.StatusTooManyRequests))
+
+				Expect(request("10.0.0.1:1234", "198.51.100.1")).To(Equal(http.StatusOK))
+				Expect(request("10.0.0.1:1234", "198.51.100.2")).To(Equal(http.StatusOK))
+				Expect(request("10.0.0.2:1234", "198.51.100.2")).To(Equal(http.StatusTooManyRequests))
+			})
+		})
+	})
+
+	Describe("graceful shutdown", func() {
+		Context("when the server is asked to stop with a request in flight", func() {
+			It("should report itself as not ready and let the request complete", func() {
+				cfg := config.Default()
+				cfg.ShutdownDelay = 50 * time.Millisecond
+				cfg.ShutdownTimeout = time.Second
+				internal.Setup(cfg)
+
+				started, release := make(chan struct{}), make(chan struct{})
+				r := gin.New()
+				r.GET("/slow", func(c *gin.Context) {
+					close(started)
+					<-release
+					c.String(http.StatusOK, "done")
+				})
+
+				ln, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+
+				ctx, stop := context.WithCancel(context.Background())
+				served := make(chan error, 1)
+				go func() {
+					served <- internal.Serve(ctx, internal.NewServer(r), ln)
+				}()
+
+				// httpmock replaces the default transport.
+				client := &http.Client{Transport: &http.Transport{}}
+				responses := make(chan *http.Response, 1)
+				go func() {
+					defer GinkgoRecover()
+					res, err := client.Get("http://" + ln.Addr().String() + "/slow")
+					Expect(err).NotTo(HaveOccurred())
+					responses <- res
+				}()
+
+				Eventually(started).Should(BeClosed())
+				stop()
+
+				Eventually(func() int {
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					internal.Health(c)
+
+					return w.Code
+				}).Should(Equal(http.StatusServiceUnavailable))
+				Consistently(served, 20*time.Millisecond).ShouldNot(Receive())
+
+				close(release)
+
+				var res *http.Response
+				Eventually(responses).Should(Receive(&res))
+				defer res.Body.Close()
+				Expect(res.StatusCode).To(Equal(http.StatusOK))
+				Eventually(served, 2*time.Second).Should(Receive(BeNil()))
+			})
+		})
+	})
+
+	Describe("/metrics", func() {
+		// scrape returns the value of every sample served by the metrics
+		// endpoint, keyed by metric name and labels.
+		scrape := func(r *gin.Engine) map[string]float64 {
+			req, _ := http.NewRequest("GET", "/metrics", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			samples := make(map[string]float64)
+			for _, line := range strings.Split(w.Body.String(), "\n") {
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+
+				i := strings.LastIndex(line, " ")
+				value, err := strconv.ParseFloat(line[i+1:], 64)
+				Expect(err).NotTo(HaveOccurred())
+				samples[line[:i]] = value
+			}
+
+			return samples
+		}
+
+		Context("when articles are searched", func() {
+			It("should count requests, cache lookups, outcomes and upstream errors", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio~&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+					httpmock.NewStringResponder(200, `{"query":{"pages":[{"ns":0,"title":"Yoshua_Bengio~","missing":true}]}}`),
+				)
+
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Readonly&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+					func(req *http.Request) (*http.Response, error) {
+						res := httpmock.NewStringResponse(200, `{"error":{"code":"readonly","info":"The wiki is currently in read-only mode."}}`)
+						res.Header.Set("MediaWiki-API-Error", "readonly")
+
+						return res, nil
+					},
+				)
+
+				r := gin.New()
+				r.Use(internal.Instrument())
+				r.GET("/api/v1/search", internal.Search)
+				r.GET("/metrics", internal.Metrics())
+
+				search := func(query string) int {
+					req, _ := http.NewRequest("GET", "/api/v1/search?query="+query, nil)
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, req)
+
+					return w.Code
+				}
+
+				before := scrape(r)
+
+				Expect(search("Yoshua_Bengio~")).To(Equal(http.StatusOK))
+				Expect(search("Yoshua_Bengio~")).To(Equal(http.StatusOK))
+				Expect(search("Readonly")).To(Equal(http.StatusServiceUnavailable))
+
+				after := scrape(r)
+				delta := func(sample string) float64 {
+					return after[sample] - before[sample]
+				}
+
+				Expect(delta(`wikipedia_api_http_requests_total{method="GET",route="/api/v1/search",status="200"}`)).To(Equal(2.0))
+				Expect(delta(`wikipedia_api_http_requests_total{method="GET",route="/api/v1/search",status="503"}`)).To(Equal(1.0))
+				Expect(delta(`wikipedia_api_http_request_duration_seconds_count{method="GET",route="/api/v1/search",status="200"}`)).To(Equal(2.0))
+				Expect(delta(`wikipedia_api_cache_requests_total{result="hit"}`)).To(Equal(1.0))
+				Expect(delta(`wikipedia_api_cache_requests_total{result="miss"}`)).To(Equal(2.0))
+				Expect(delta(`wikipedia_api_lookups_total{outcome="missing"}`)).To(Equal(2.0))
+				Expect(delta(`wikipedia_api_upstream_errors_total{code="readonly"}`)).To(Equal(1.0))
+				Expect(delta(`wikipedia_api_upstream_request_duration_seconds_count{host="en.wikipedia.org"}`)).To(Equal(2.0))
+				Expect(after).To(HaveKey("wikipedia_api_cache_hit_ratio"))
+				Expect(after["wikipedia_api_cache_entries"]).To(Equal(1.0))
+				Expect(after["wikipedia_api_circuit_breaker_state"]).To(Equal(0.0))
+			})
+		})
+	})
+
+	Describe("tracing", func() {
+		Context("when a client sends a traceparent header", func() {
+			It("should record the spans of the request in its trace and pass it on upstream", func() {
+				cfg := config.Default()
+				cfg.TracingExporter = "stdout"
+				internal.Setup(cfg)
+
+				var spans bytes.Buffer
+				shutdown, err := tracing.Setup(cfg, &spans)
+				Expect(err).NotTo(HaveOccurred())
+				defer shutdown(context.Background())
+
+				var upstreamHeaders http.Header
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+					func(req *http.Request) (*http.Response, error) {
+						upstreamHeaders = req.Header
+
+						return httpmock.NewStringResponse(200, `{"query":{"pages":[{"pageid":47749536,"ns":0,"title":"Yoshua Bengio","revisions":[{"content":"{{Short description|Canadian computer scientist}}"}]}]}}`), nil
+					},
+				)
+
+				r := gin.New()
+				r.Use(func(c *gin.Context) {
+					c.Set("reqID", "4e1c4ad2-0a7a-4fb3-8f3b-3d7cbbd0b9a1")
+					c.Next()
+				})
+				r.Use(internal.Trace())
+				r.GET("/api/v1/search", internal.Search)
+
+				req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio&api_key=s3cret", nil)
+				req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(upstreamHeaders.Get("traceparent")).To(HavePrefix("00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+				Expect(upstreamHeaders.Get("X-Request-Id")).To(Equal("4e1c4ad2-0a7a-4fb3-8f3b-3d7cbbd0b9a1"))
+
+				type span struct {
+					Name        string
+					SpanContext struct{ TraceID, SpanID string }
+					Parent      struct{ SpanID string }
+				}
+
+				exported := spans.String()
+				Expect(exported).To(ContainSubstring(`"Key":"http.target","Value":{"Type":"STRING","Value":"/api/v1/search?query=Yoshua_Bengio\u0026api_key=REDACTED"}`))
+				Expect(exported).NotTo(ContainSubstring("s3cret"))
+
+				var recorded []span
+				decoder := json.NewDecoder(strings.NewReader(exported))
+				for decoder.More() {
+					var s span
+					Expect(decoder.Decode(&s)).To(Succeed())
+					Expect(s.SpanContext.TraceID).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+					recorded = append(recorded, s)
+				}
+
+				names := make(map[string]span)
+				for _, s := range recorded {
+					names[s.Name] = s
+				}
+
+				Expect(names).To(HaveKey("cache.Get"))
+				Expect(names).To(HaveKey("wikitext.ShortDescription"))
+				Expect(names["GET /api/v1/search"].Parent.SpanID).To(Equal("00f067aa0ba902b7"))
+				Expect(names["wikipedia.ShortDescription"].Parent.SpanID).To(Equal(names["GET /api/v1/search"].SpanContext.SpanID))
+				Expect(names["HTTP GET"].Parent.SpanID).To(Equal(names["wikipedia.ShortDescription"].SpanContext.SpanID))
+				Expect(upstreamHeaders.Get("traceparent")).To(ContainSubstring(names["HTTP GET"].SpanContext.SpanID))
 			})
 		})
 	})
@@ -64,12 +734,58 @@ var _ = Describe("WikipediaApi", func() {
 			})
 		})
 
+		Context("when the query parameter is not a valid title", func() {
+			It("should return 400 and name the broken rule", func() {
+				req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim%7CYoshua_Bengio", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Search(c)
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(response.Errors[0].Rule).To(Equal("illegal_character"))
+				Expect(response.Errors[0].Detail).To(Equal("Invalid query parameter. The title contains the illegal character '|'."))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the query parameter names a page of another wiki", func() {
+			It("should return 400 with the interwiki rule", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=fr:Paris&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(200, `{"batchcomplete": true, "query": {"interwiki": [{"title": "fr:Paris", "iw": "fr"}]}}`),
+				)
+
+				req, _ := http.NewRequest("GET", "/api/v1/search?query=fr:Paris", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Search(c)
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(response.Errors[0].Rule).To(Equal("interwiki"))
+				Expect(response.Errors[0].Detail).To(Equal("Invalid query parameter. The title starts with the interwiki prefix 'fr:' and names a page of another wiki."))
+			})
+		})
+
 		Context("when the query parameter is present", func() {
 			Context("when the Wikipedia API returns the result we are looking for", func() {
 				It("should return 200 and the short description", func() {
-					httpmock.RegisterResponder(
+					httpmock.RegisterResponderWithQuery(
 						"GET",
-						"https://en.wikipedia.org/w/api.php?action=query&prop=revisions&titles=Yoshua_Bengio&rvlimit=1&formatversion=2&format=json&rvprop=content",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
 
 						httpmock.NewStringResponder(
 							200,
@@ -106,24 +822,34 @@ var _ = Describe("WikipediaApi", func() {
 
 					Expect(w.Code).To(Equal(http.StatusOK))
 					Expect(response.Data.ShortDescription).To(Equal("Canadian computer scientist"))
+					Expect(response.Data.Source).To(Equal("wikitext"))
 				})
 			})
 
-			Context("when the Wikipedia API returns that the page is missing", func() {
-				It("should return 200 and a 'No wikipedia article found.' message", func() {
-					httpmock.RegisterResponder(
+			Context("when the query is a redirect", func() {
+				It("should return 200, the short description of the target and the redirect chain", func() {
+					httpmock.RegisterResponderWithQuery(
 						"GET",
-						"https://en.wikipedia.org/w/api.php?action=query&prop=revisions&titles=Yoshua_Bengio~&rvlimit=1&formatversion=2&format=json&rvprop=content",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=USA&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
 
 						httpmock.NewStringResponder(
 							200,
 							`{
 								"query": {
+									"redirects": [
+										{"from": "USA", "to": "United States"}
+									],
 									"pages": [
 										{
+											"pageid": 3434750,
 											"ns": 0,
-											"title": "Yoshua_Bengio~",
-											"missing": true
+											"title": "United States",
+											"revisions": [
+												{
+													"content": "{{Short description|Country in North America}}"
+												}
+											]
 										}
 									]
 								}
@@ -131,79 +857,60 @@ var _ = Describe("WikipediaApi", func() {
 						),
 					)
 
-					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio~", nil)
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=USA", nil)
 					w := httptest.NewRecorder()
 					c, _ := gin.CreateTestContext(w)
 					c.Request = req
 					internal.Search(c)
-					var response internal.MissingResponse
+					var response internal.SuccessResponse
 					json.Unmarshal(w.Body.Bytes(), &response)
 
 					defer w.Result().Body.Close()
 
 					Expect(w.Code).To(Equal(http.StatusOK))
-					Expect(response.Status).To(Equal("success"))
-					Expect(response.Message).To(Equal("No wikipedia article found."))
-					Expect(response.Missing).To(Equal(true))
+					Expect(response.Data.ShortDescription).To(Equal("Country in North America"))
+					Expect(response.Data.Title).To(Equal("United States"))
+					Expect(response.Data.PageID).To(Equal(3434750))
+					Expect(response.Data.Redirects).To(Equal([]internal.Redirect{{From: "USA", To: "United States"}}))
 				})
 			})
 
-			Context("when the Wikipedia API does not return a short description", func() {
-				It("should return 200 and a 'No short description found for this article.' message", func() {
-					httpmock.RegisterResponder(
+			Context("when a supported language is requested", func() {
+				It("should query that language edition of Wikipedia", func() {
+					cfg := config.Default()
+					cfg.Languages = []string{"en", "de"}
+					internal.Setup(cfg)
+
+					httpmock.RegisterResponderWithQuery(
 						"GET",
-						"https://en.wikipedia.org/w/api.php?action=query&prop=revisions&titles=Kim&rvlimit=1&formatversion=2&format=json&rvprop=content",
+						"https://de.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Berlin&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
 
 						httpmock.NewStringResponder(
 							200,
-							`{
-								"query": {
-									"pages": [
-										{
-											"pageid": 627030,
-											"ns": 0,
-											"title": "Kim",
-											"revisions": [
-												{
-													"contentformat": "text/x-wiki",
-													"contentmodel": "wikitext",
-													"content": "{{wiktionary|Kim|kim}}"
-												}
-											]
-										}
-									]
-								}
-							}`,
+							`{"query": {"pages": [{"pageid": 2627, "ns": 0, "title": "Berlin", "revisions": [{"content": "{{Short description|Hauptstadt von Deutschland}}"}]}]}}`,
 						),
 					)
 
-					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Berlin&lang=de", nil)
 					w := httptest.NewRecorder()
 					c, _ := gin.CreateTestContext(w)
 					c.Request = req
 					internal.Search(c)
-					var response internal.NoDescriptionResponse
+					var response internal.SuccessResponse
 					json.Unmarshal(w.Body.Bytes(), &response)
 
 					defer w.Result().Body.Close()
 
 					Expect(w.Code).To(Equal(http.StatusOK))
-					Expect(response.Status).To(Equal("success"))
-					Expect(response.Message).To(Equal("No short description found for this article."))
-					Expect(response.Missing).To(Equal(false))
+					Expect(response.Data.ShortDescription).To(Equal("Hauptstadt von Deutschland"))
+					Expect(response.Data.Language).To(Equal("de"))
 				})
 			})
 
-			Context("when the Wikipedia API returns an error", func() {
-				It("should return 500 and a 'Wikipedia API error.' message", func() {
-					httpmock.RegisterResponder(
-						"GET",
-						"https://en.wikipedia.org/w/api.php?action=query&prop=revisions&titles=Kim&rvlimit=1&formatversion=2&format=json&rvprop=content",
-
-						httpmock.NewStringResponder(500, `{}`),
-					)
-
-					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+			Context("when an unsupported language is requested", func() {
+				It("should return 400 and name the supported languages", func() {
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Berlin&lang=xx", nil)
 					w := httptest.NewRecorder()
 					c, _ := gin.CreateTestContext(w)
 					c.Request = req
@@ -213,12 +920,1274 @@ var _ = Describe("WikipediaApi", func() {
 
 					defer w.Result().Body.Close()
 
-					Expect(w.Code).To(Equal(http.StatusInternalServerError))
+					Expect(w.Code).To(Equal(http.StatusBadRequest))
+					Expect(response.Errors[0].Detail).To(Equal("Unsupported language 'xx'. Supported languages are: en."))
+				})
+			})
+
+			Context("when the Wikipedia API returns that the page is missing", func() {
+				It("should return 200 and a 'No wikipedia article found.' message", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio~&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(
+							200,
+							`{
+								"query": {
+									"pages": [
+										{
+											"ns": 0,
+											"title": "Yoshua_Bengio~",
+											"missing": true
+										}
+									]
+								}
+							}`,
+						),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio~", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.MissingResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Status).To(Equal("success"))
+					Expect(response.Message).To(Equal("No wikipedia article found."))
+					Expect(response.Missing).To(Equal(true))
+				})
+			})
+
+			Context("when the Wikipedia API does not return a short description", func() {
+				It("should return 200 and a 'No short description found for this article.' message", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(
+							200,
+							`{
+								"query": {
+									"pages": [
+										{
+											"pageid": 627030,
+											"ns": 0,
+											"title": "Kim",
+											"revisions": [
+												{
+													"contentformat": "text/x-wiki",
+													"contentmodel": "wikitext",
+													"content": "{{wiktionary|Kim|kim}}"
+												}
+											]
+										}
+									]
+								}
+							}`,
+						),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.NoDescriptionResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Status).To(Equal("success"))
+					Expect(response.Message).To(Equal("No short description found for this article."))
+					Expect(response.Missing).To(Equal(false))
+				})
+			})
+
+			Context("when the page is missing and did_you_mean is set", func() {
+				It("should return the best matching titles and the spelling suggestion", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengoi&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(200, `{"query": {"pages": [{"ns": 0, "title": "Yoshua Bengoi", "missing": true}]}}`),
+					)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&list=search&srsearch=Yoshua%20Bengoi&srnamespace=0&srlimit=5&srinfo=suggestion&srprop=&formatversion=2&format=json&maxlag=5",
+
+						httpmock.NewStringResponder(
+							200,
+							`{
+								"query": {
+									"searchinfo": {"suggestion": "yoshua bengio"},
+									"search": [
+										{"ns": 0, "title": "Yoshua Bengio", "pageid": 47749536},
+										{"ns": 0, "title": "Samy Bengio", "pageid": 52937214}
+									]
+								}
+							}`,
+						),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengoi&did_you_mean=1", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.MissingResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Missing).To(Equal(true))
+					Expect(response.Suggestions).To(Equal([]string{"Yoshua Bengio", "Samy Bengio"}))
+					Expect(response.DidYouMean).To(Equal("yoshua bengio"))
+				})
+			})
+
+			Context("when did_you_mean is not a boolean", func() {
+				It("should return 400 without calling the wikipedia API", func() {
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengoi&did_you_mean=maybe", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusBadRequest))
+					Expect(response.Errors[0].Detail).To(Equal("The did_you_mean parameter must be true or false."))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the same query is searched twice", func() {
+				It("should serve the second response from the cache", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio~&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(200, `{"query": {"pages": [{"ns": 0, "title": "Yoshua_Bengio~", "missing": true}]}}`),
+					)
+
+					var cacheHeaders []string
+					for i := 0; i < 2; i++ {
+						req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio~", nil)
+						w := httptest.NewRecorder()
+						c, _ := gin.CreateTestContext(w)
+						c.Request = req
+						internal.Search(c)
+						var response internal.MissingResponse
+						json.Unmarshal(w.Body.Bytes(), &response)
+
+						Expect(w.Code).To(Equal(http.StatusOK))
+						Expect(response.Missing).To(Equal(true))
+						cacheHeaders = append(cacheHeaders, w.Header().Get("X-Cache"))
+					}
+
+					Expect(cacheHeaders).To(Equal([]string{"MISS", "HIT"}))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the same article is searched with a different spelling of its title", func() {
+				It("should serve the second response from the cache", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(200, `{"query": {"normalized": [{"from": "yoshua_Bengio", "to": "Yoshua Bengio"}], "pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`),
+					)
+
+					var cacheHeaders []string
+					for _, query := range []string{"yoshua_Bengio", "Yoshua%20Bengio"} {
+						req, _ := http.NewRequest("GET", "/api/v1/search?query="+query, nil)
+						w := httptest.NewRecorder()
+						c, _ := gin.CreateTestContext(w)
+						c.Request = req
+						internal.Search(c)
+						var response internal.SuccessResponse
+						json.Unmarshal(w.Body.Bytes(), &response)
+
+						Expect(w.Code).To(Equal(http.StatusOK))
+						Expect(response.Data.ShortDescription).To(Equal("Canadian computer scientist"))
+						cacheHeaders = append(cacheHeaders, w.Header().Get("X-Cache"))
+					}
+
+					Expect(cacheHeaders).To(Equal([]string{"MISS", "HIT"}))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the same query is searched concurrently", func() {
+				It("should share one upstream call, even if the first client goes away", func() {
+					started := make(chan struct{})
+					release := make(chan struct{})
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							close(started)
+							<-release
+
+							return httpmock.NewStringResponse(200, `{"query": {"pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`), nil
+						},
+					)
+
+					search := func(ctx context.Context) *httptest.ResponseRecorder {
+						req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/search?query=Yoshua_Bengio", nil)
+						w := httptest.NewRecorder()
+						c, _ := gin.CreateTestContext(w)
+						c.Request = req
+						internal.Search(c)
+
+						return w
+					}
+
+					ctx, cancel := context.WithCancel(context.Background())
+					leader := make(chan *httptest.ResponseRecorder)
+					go func() {
+						leader <- search(ctx)
+					}()
+
+					<-started
+					cancel()
+					Expect((<-leader).Code).To(Equal(499))
+
+					follower := make(chan *httptest.ResponseRecorder)
+					go func() {
+						follower <- search(context.Background())
+					}()
+
+					close(release)
+					w := <-follower
+					var response internal.SuccessResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Data.ShortDescription).To(Equal("Canadian computer scientist"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the article has no short description template", func() {
+				It("should fall back to the description of the Wikidata entity", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(
+							200,
+							`{"query": {"pages": [{"pageid": 627030, "ns": 0, "title": "Kim", "revisions": [{"content": "{{wiktionary|Kim|kim}}"}], "pageprops": {"wikibase_item": "Q1"}}]}}`,
+						),
+					)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://www.wikidata.org/w/api.php",
+						"action=wbgetentities&ids=Q1&props=descriptions&languages=en&formatversion=2&format=json&maxlag=5",
+
+						httpmock.NewStringResponder(
+							200,
+							`{"entities": {"Q1": {"descriptions": {"en": {"language": "en", "value": "given name"}}}}}`,
+						),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.SuccessResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Data.ShortDescription).To(Equal("given name"))
+					Expect(response.Data.Source).To(Equal("wikidata"))
+				})
+			})
+
+			Context("when the Wikipedia API does not respond in time", func() {
+				It("should return 504 and a timeout message", func() {
+					cfg := config.Default()
+					cfg.UpstreamTimeout = 20 * time.Millisecond
+					internal.Setup(cfg)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							time.Sleep(time.Second)
+
+							return httpmock.NewStringResponse(200, `{}`), nil
+						},
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusGatewayTimeout))
+					Expect(response.Errors[0].Detail).To(Equal("The wikipedia API did not respond in time. Please try again later."))
+				})
+			})
+
+			Context("when the Wikipedia API is temporarily unavailable", func() {
+				It("should retry and return 200 and the short description", func() {
+					cfg := config.Default()
+					cfg.RetryBaseDelay = time.Millisecond
+					internal.Setup(cfg)
+
+					unavailable := httpmock.NewStringResponse(503, `{}`)
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.ResponderFromMultipleResponses([]*http.Response{
+							unavailable,
+							httpmock.NewStringResponse(200, `{"query": {"pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`),
+						}),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Yoshua_Bengio", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.SuccessResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(response.Data.ShortDescription).To(Equal("Canadian computer scientist"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+				})
+
+				It("should not retry if Retry-After exceeds the upstream timeout", func() {
+					cfg := config.Default()
+					cfg.UpstreamTimeout = time.Second
+					internal.Setup(cfg)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							resp := httpmock.NewStringResponse(429, `{}`)
+							resp.Header.Set("Retry-After", "30")
+
+							return resp, nil
+						},
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusInternalServerError))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+
+				It("should not wait for a Retry-After longer than the configured maximum without an upstream timeout", func() {
+					cfg := config.Default()
+					cfg.UpstreamTimeout = 0
+					cfg.RetryMaxRetryAfter = time.Second
+					internal.Setup(cfg)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							resp := httpmock.NewStringResponse(503, `{}`)
+							resp.Header.Set("Retry-After", "3")
+
+							return resp, nil
+						},
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+
+					start := time.Now()
+					internal.Search(c)
+
+					defer w.Result().Body.Close()
+
+					Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+					Expect(w.Code).To(Equal(http.StatusInternalServerError))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the Wikipedia API database replicas are lagged", func() {
+				It("should identify itself, back off and return 503 if the lag outlasts the upstream timeout", func() {
+					var userAgent string
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							userAgent = req.Header.Get("User-Agent")
+							resp := httpmock.NewStringResponse(200, `{"error": {"code": "maxlag", "info": "Waiting for 10.64.48.23: 30 seconds lagged.", "lag": 30}}`)
+							resp.Header.Set("MediaWiki-API-Error", "maxlag")
+							resp.Header.Set("Retry-After", "30")
+
+							return resp, nil
+						},
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(w.Header().Get("Retry-After")).To(Equal("30"))
+					Expect(userAgent).To(Equal("wikipedia-api (https://github.com/youssef1337/wikipedia-api)"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+
+				It("should fail requests queued behind the lagged one fast instead of sending them", func() {
+					started := make(chan struct{})
+					release := make(chan struct{})
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							close(started)
+							<-release
+
+							resp := httpmock.NewStringResponse(200, `{"error": {"code": "maxlag", "info": "Waiting for 10.64.48.23: 30 seconds lagged.", "lag": 30}}`)
+							resp.Header.Set("MediaWiki-API-Error", "maxlag")
+							resp.Header.Set("Retry-After", "30")
+
+							return resp, nil
+						},
+					)
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+						httpmock.NewStringResponder(200, `{"query": {"pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`),
+					)
+
+					search := func(query string) *httptest.ResponseRecorder {
+						req, _ := http.NewRequest("GET", "/api/v1/search?query="+query, nil)
+						w := httptest.NewRecorder()
+						c, _ := gin.CreateTestContext(w)
+						c.Request = req
+						internal.Search(c)
+
+						return w
+					}
+
+					lagged := make(chan *httptest.ResponseRecorder)
+					go func() {
+						lagged <- search("Kim")
+					}()
+
+					<-started
+					queued := make(chan *httptest.ResponseRecorder)
+					go func() {
+						queued <- search("Yoshua_Bengio")
+					}()
+
+					// Let the second request wait for the only upstream slot.
+					time.Sleep(50 * time.Millisecond)
+					close(release)
+
+					Expect((<-lagged).Code).To(Equal(http.StatusServiceUnavailable))
+
+					w := <-queued
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(w.Header().Get("Retry-After")).To(Equal("30"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+
+				It("should back off when the error is only reported in the body", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+						httpmock.NewStringResponder(200, `{"error": {"code": "maxlag", "info": "Waiting for 10.64.48.23: 29.4 seconds lagged.", "lag": 29.4}}`),
+					)
+
+					start := time.Now()
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(w.Header().Get("Retry-After")).To(Equal("30"))
+					Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when the Wikipedia API reports an error in the response body", func() {
+				It("should return the mapped status and the upstream error code", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(200, `{"error": {"code": "readonly", "info": "The wiki is currently in read-only mode."}}`),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(response.Errors[0].UpstreamCode).To(Equal("readonly"))
+					Expect(response.Errors[0].Detail).To(Equal("The wikipedia API reported an error: The wiki is currently in read-only mode."))
+				})
+			})
+
+			Context("when the Wikipedia API returns no pages", func() {
+				It("should return 502 instead of failing", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(200, `{"batchcomplete": true, "warnings": {"main": {"warnings": "Unrecognized parameter: foo."}}, "query": {"pages": []}}`),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusBadGateway))
+					Expect(response.Errors[0].Detail).To(Equal("The wikipedia API returned an unexpected response. Please try again later."))
+				})
+			})
+
+			Context("when the Wikipedia API returns an error", func() {
+				It("should return 500 and a 'Wikipedia API error.' message", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(500, `{}`),
+					)
+
+					req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					defer w.Result().Body.Close()
+
+					Expect(w.Code).To(Equal(http.StatusInternalServerError))
 					Expect(response.Status).To(Equal("error"))
 					Expect(response.Errors[0].Detail).To(Equal("An error occurred while communicating with the wikipedia API with http code 500. Please find more information at https://en.wikipedia.org/w/api.php."))
 				})
 			})
 
+			Context("when the Wikipedia API keeps failing", func() {
+				It("should open the circuit breaker and return 503 without calling the Wikipedia API", func() {
+					cfg := config.Default()
+					cfg.RetryMaxAttempts = 1
+					cfg.BreakerFailureThreshold = 2
+					cfg.BreakerCooldown = time.Minute
+					internal.Setup(cfg)
+
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						httpmock.NewStringResponder(503, `{}`),
+					)
+
+					search := func() *httptest.ResponseRecorder {
+						req, _ := http.NewRequest("GET", "/api/v1/search?query=Kim", nil)
+						w := httptest.NewRecorder()
+						c, _ := gin.CreateTestContext(w)
+						c.Request = req
+						internal.Search(c)
+
+						return w
+					}
+
+					Expect(search().Code).To(Equal(http.StatusInternalServerError))
+					Expect(search().Code).To(Equal(http.StatusInternalServerError))
+
+					w := search()
+					var response internal.ErrorResponse
+					json.Unmarshal(w.Body.Bytes(), &response)
+
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(w.Header().Get("Retry-After")).To(Equal("60"))
+					Expect(response.Errors[0].Detail).To(Equal("The wikipedia API is currently unavailable. Please try again later."))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+
+					w = httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					internal.Health(c)
+					var health internal.CheckHealthResponse
+					json.Unmarshal(w.Body.Bytes(), &health)
+
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(health.Status).To(Equal("degraded"))
+					Expect(health.CircuitBreaker).To(Equal("open"))
+				})
+			})
+
+			Context("when the circuit breaker lets a probe through after the cooldown", func() {
+				const kimQuery = "action=query&prop=revisions|description|pageprops&titles=Kim&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5"
+
+				search := func(query string) *httptest.ResponseRecorder {
+					req, _ := http.NewRequest("GET", "/api/v1/search?query="+query, nil)
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					c.Request = req
+					internal.Search(c)
+
+					return w
+				}
+
+				breakerState := func() string {
+					w := httptest.NewRecorder()
+					c, _ := gin.CreateTestContext(w)
+					internal.Health(c)
+					var health internal.CheckHealthResponse
+					json.Unmarshal(w.Body.Bytes(), &health)
+
+					return health.CircuitBreaker
+				}
+
+				BeforeEach(func() {
+					cfg := config.Default()
+					cfg.RetryMaxAttempts = 1
+					cfg.BreakerFailureThreshold = 1
+					cfg.BreakerCooldown = 20 * time.Millisecond
+					cfg.UpstreamMaxConcurrency = 2
+					internal.Setup(cfg)
+				})
+
+				It("should close the breaker when the probe succeeds", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						kimQuery,
+						httpmock.ResponderFromMultipleResponses([]*http.Response{
+							httpmock.NewStringResponse(503, `{}`),
+							httpmock.NewStringResponse(200, `{"query": {"pages": [{"pageid": 627030, "ns": 0, "title": "Kim", "revisions": [{"content": "{{Short description|Given name}}"}]}]}}`),
+						}),
+					)
+
+					Expect(search("Kim").Code).To(Equal(http.StatusInternalServerError))
+					Expect(breakerState()).To(Equal("open"))
+
+					time.Sleep(30 * time.Millisecond)
+					Expect(breakerState()).To(Equal("half-open"))
+
+					Expect(search("Kim").Code).To(Equal(http.StatusOK))
+					Expect(breakerState()).To(Equal("closed"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+				})
+
+				It("should open the breaker again when the probe fails", func() {
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						kimQuery,
+						httpmock.NewStringResponder(503, `{}`),
+					)
+
+					Expect(search("Kim").Code).To(Equal(http.StatusInternalServerError))
+
+					time.Sleep(30 * time.Millisecond)
+					Expect(search("Kim").Code).To(Equal(http.StatusInternalServerError))
+					Expect(breakerState()).To(Equal("open"))
+
+					w := search("Kim")
+					Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+					Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+					Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+				})
+
+				It("should leave the outcome to the probe when an older request finishes meanwhile", func() {
+					slowStarted, releaseSlow := make(chan struct{}), make(chan struct{})
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&rvlimit=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+						func(req *http.Request) (*http.Response, error) {
+							close(slowStarted)
+							<-releaseSlow
+
+							return httpmock.NewStringResponse(503, `{}`), nil
+						},
+					)
+
+					probeStarted, releaseProbe := make(chan struct{}), make(chan struct{})
+					calls := 0
+					httpmock.RegisterResponderWithQuery(
+						"GET",
+						"https://en.wikipedia.org/w/api.php",
+						kimQuery,
+						func(req *http.Request) (*http.Response, error) {
+							calls++
+							if calls == 1 {
+								return httpmock.NewStringResponse(503, `{}`), nil
+							}
+
+							close(probeStarted)
+							<-releaseProbe
+
+							return httpmock.NewStringResponse(200, `{"query": {"pages": [{"pageid": 627030, "ns": 0, "title": "Kim", "revisions": [{"content": "{{Short description|Given name}}"}]}]}}`), nil
+						},
+					)
+
+					slow := make(chan *httptest.ResponseRecorder)
+					go func() {
+						slow <- search("Yoshua_Bengio")
+					}()
+					<-slowStarted
+
+					Expect(search("Kim").Code).To(Equal(http.StatusInternalServerError))
+					time.Sleep(30 * time.Millisecond)
+
+					probe := make(chan *httptest.ResponseRecorder)
+					go func() {
+						probe <- search("Kim")
+					}()
+					<-probeStarted
+
+					close(releaseSlow)
+					Expect((<-slow).Code).To(Equal(http.StatusInternalServerError))
+					Expect(breakerState()).To(Equal("half-open"))
+
+					close(releaseProbe)
+					Expect((<-probe).Code).To(Equal(http.StatusOK))
+					Expect(breakerState()).To(Equal("closed"))
+				})
+			})
+
+		})
+	})
+
+	Describe("/summary", func() {
+		Context("when a number of sentences is requested", func() {
+			It("should return 200 and the plain-text lead section", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=extracts&titles=Yoshua_Bengio&redirects=1&exintro=1&explaintext=1&exsentences=1&formatversion=2&format=json&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"query": {
+								"normalized": [{"from": "Yoshua_Bengio", "to": "Yoshua Bengio"}],
+								"pages": [
+									{
+										"pageid": 47749536,
+										"ns": 0,
+										"title": "Yoshua Bengio",
+										"extract": "Yoshua Bengio is a Canadian computer scientist."
+									}
+								]
+							}
+						}`,
+					),
+				)
+
+				req, _ := http.NewRequest("GET", "/api/v1/summary?query=Yoshua_Bengio&sentences=1", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Summary(c)
+				var response internal.SummaryResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(response.Data.Summary).To(Equal("Yoshua Bengio is a Canadian computer scientist."))
+				Expect(response.Data.Title).To(Equal("Yoshua Bengio"))
+			})
+		})
+
+		Context("when neither sentences nor chars is requested", func() {
+			It("should limit the summary to the configured maximum number of sentences", func() {
+				cfg := config.Default()
+				cfg.SummaryMaxSentences = 3
+				internal.Setup(cfg)
+
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=extracts&titles=Yoshua_Bengio&redirects=1&exintro=1&explaintext=1&exsentences=3&formatversion=2&format=json&maxlag=5",
+
+					httpmock.NewStringResponder(200, `{"query": {"pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "extract": "Yoshua Bengio is a Canadian computer scientist."}]}}`),
+				)
+
+				req, _ := http.NewRequest("GET", "/api/v1/summary?query=Yoshua_Bengio", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Summary(c)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when too many sentences are requested", func() {
+			It("should return 400 and name the allowed range", func() {
+				req, _ := http.NewRequest("GET", "/api/v1/summary?query=Yoshua_Bengio&sentences=50", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Summary(c)
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(response.Errors[0].Detail).To(Equal("The sentences parameter must be an integer between 1 and 10."))
+			})
+		})
+
+		Context("when the query parameter names a page of another wiki", func() {
+			It("should return 400 with the interwiki rule", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=extracts&titles=fr:Paris&redirects=1&exintro=1&explaintext=1&exsentences=10&formatversion=2&format=json&maxlag=5",
+
+					httpmock.NewStringResponder(200, `{"batchcomplete": true, "query": {"interwiki": [{"title": "fr:Paris", "iw": "fr"}]}}`),
+				)
+
+				req, _ := http.NewRequest("GET", "/api/v1/summary?query=fr:Paris", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Summary(c)
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(response.Errors[0].Rule).To(Equal("interwiki"))
+			})
+		})
+	})
+
+	Describe("/suggest", func() {
+		Context("when the prefix matches articles", func() {
+			It("should return 200 and the ranked titles with their short descriptions", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&generator=prefixsearch&gpssearch=Yoshua&gpsnamespace=0&gpslimit=10&prop=description&descprefersource=local&redirects=1&formatversion=2&format=json&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"query": {
+								"redirects": [
+									{"index": 1, "from": "Yoshua", "to": "Yoshua (given name)"}
+								],
+								"pages": [
+									{
+										"pageid": 47749536,
+										"ns": 0,
+										"title": "Yoshua Bengio",
+										"index": 2,
+										"description": "Canadian computer scientist",
+										"descriptionsource": "local"
+									},
+									{
+										"pageid": 123,
+										"ns": 0,
+										"title": "Yoshua (given name)",
+										"description": "given name",
+										"descriptionsource": "central"
+									}
+								]
+							}
+						}`,
+					),
+				)
+
+				req, _ := http.NewRequest("GET", "/api/v1/suggest?prefix=Yoshua", nil)
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.Suggest(c)
+				var response internal.SuggestResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(response.Data).To(Equal([]internal.Suggestion{
+					{Title: "Yoshua (given name)", PageID: 123, ShortDescription: "given name", Source: "wikidata"},
+					{Title: "Yoshua Bengio", PageID: 47749536, ShortDescription: "Canadian computer scientist", Source: "description"},
+				}))
+			})
+		})
+	})
+
+	Describe("/search/batch", func() {
+		Context("when the request body is empty", func() {
+			It("should return 400 and a 'Request body must be a non-empty JSON array of titles.' message", func() {
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(`[]`))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response internal.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(response.Errors[0].Detail).To(Equal("Request body must be a non-empty JSON array of titles."))
+			})
+		})
+
+		Context("when one of the titles is invalid", func() {
+			It("should report it in its own result and look up the other titles", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(200, `{"query": {"normalized": [{"from": "Yoshua_Bengio", "to": "Yoshua Bengio"}], "pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`),
+				)
+
+				body := `["Yoshua_Bengio", "Yoshua|Bengio"]`
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response struct {
+					Data []struct {
+						Query  string                 `json:"query"`
+						Result map[string]interface{} `json:"result"`
+					} `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				Expect(response.Data).To(HaveLen(2))
+				Expect(response.Data[0].Result["data"]).To(HaveKeyWithValue("short_description", "Canadian computer scientist"))
+				Expect(response.Data[1].Query).To(Equal("Yoshua|Bengio"))
+				Expect(response.Data[1].Result["status"]).To(Equal("error"))
+				Expect(response.Data[1].Result["errors"]).To(ConsistOf(SatisfyAll(
+					HaveKeyWithValue("code", 400.0),
+					HaveKeyWithValue("rule", "illegal_character"),
+					HaveKeyWithValue("detail", "Invalid titles[1] parameter. The title contains the illegal character '|'."),
+				)))
+			})
+		})
+
+		Context("when one of the titles names a page of another wiki", func() {
+			It("should report the interwiki rule in its own result", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio|fr:Paris&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(200, `{"batchcomplete": true, "query": {"normalized": [{"from": "Yoshua_Bengio", "to": "Yoshua Bengio"}], "interwiki": [{"title": "fr:Paris", "iw": "fr"}], "pages": [{"pageid": 47749536, "ns": 0, "title": "Yoshua Bengio", "revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]}]}}`),
+				)
+
+				body := `["Yoshua_Bengio", "fr:Paris"]`
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response struct {
+					Data []struct {
+						Result map[string]interface{} `json:"result"`
+					} `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(response.Data).To(HaveLen(2))
+				Expect(response.Data[0].Result["data"]).To(HaveKeyWithValue("short_description", "Canadian computer scientist"))
+				Expect(response.Data[1].Result["errors"]).To(ConsistOf(SatisfyAll(
+					HaveKeyWithValue("code", 400.0),
+					HaveKeyWithValue("rule", "interwiki"),
+				)))
+			})
+		})
+
+		Context("when the request body contains titles", func() {
+			It("should return one result per title in a single upstream query", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Yoshua_Bengio|Yoshua_Bengio~|Kim&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"query": {
+								"normalized": [
+									{"fromencoded": false, "from": "Yoshua_Bengio", "to": "Yoshua Bengio"}
+								],
+								"pages": [
+									{
+										"ns": 0,
+										"title": "Yoshua_Bengio~",
+										"missing": true
+									},
+									{
+										"pageid": 627030,
+										"ns": 0,
+										"title": "Kim",
+										"revisions": [{"content": "{{wiktionary|Kim|kim}}"}]
+									},
+									{
+										"pageid": 47749536,
+										"ns": 0,
+										"title": "Yoshua Bengio",
+										"revisions": [{"content": "{{Short description|Canadian computer scientist}}"}]
+									}
+								]
+							}
+						}`,
+					),
+				)
+
+				body := `["Yoshua_Bengio", "Yoshua_Bengio~", "Kim", "Yoshua_Bengio"]`
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response struct {
+					Status string `json:"status"`
+					Data   []struct {
+						Query  string                 `json:"query"`
+						Result map[string]interface{} `json:"result"`
+					} `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				defer w.Result().Body.Close()
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(1))
+				Expect(response.Data).To(HaveLen(4))
+				Expect(response.Data[0].Query).To(Equal("Yoshua_Bengio"))
+				Expect(response.Data[0].Result["data"]).To(HaveKeyWithValue("short_description", "Canadian computer scientist"))
+				Expect(response.Data[1].Result["message"]).To(Equal("No wikipedia article found."))
+				Expect(response.Data[2].Result["message"]).To(Equal("No short description found for this article."))
+				Expect(response.Data[3].Result["data"]).To(HaveKeyWithValue("short_description", "Canadian computer scientist"))
+			})
+		})
+
+		Context("when the Wikidata item of one of the articles has been deleted", func() {
+			It("should still return the Wikidata descriptions of the other articles", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Kim|Alan_Turing|Ada_Lovelace&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"query": {
+								"normalized": [
+									{"fromencoded": false, "from": "Alan_Turing", "to": "Alan Turing"},
+									{"fromencoded": false, "from": "Ada_Lovelace", "to": "Ada Lovelace"}
+								],
+								"pages": [
+									{"pageid": 627030, "ns": 0, "title": "Kim", "revisions": [{"content": "{{wiktionary|Kim|kim}}"}], "pageprops": {"wikibase_item": "Q1"}},
+									{"pageid": 1208, "ns": 0, "title": "Alan Turing", "revisions": [{"content": "Alan Turing was a mathematician."}], "pageprops": {"wikibase_item": "Q404"}},
+									{"pageid": 974, "ns": 0, "title": "Ada Lovelace", "revisions": [{"content": "Ada Lovelace was a mathematician."}], "pageprops": {"wikibase_item": "Q7259"}}
+								]
+							}
+						}`,
+					),
+				)
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://www.wikidata.org/w/api.php",
+					"action=wbgetentities&ids=Q1|Q404|Q7259&props=descriptions&languages=en&formatversion=2&format=json&maxlag=5",
+					httpmock.NewStringResponder(200, `{"error": {"code": "no-such-entity", "info": "Could not find an entity with the ID \"Q404\".", "id": "Q404"}}`),
+				)
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://www.wikidata.org/w/api.php",
+					"action=wbgetentities&ids=Q1|Q7259&props=descriptions&languages=en&formatversion=2&format=json&maxlag=5",
+					httpmock.NewStringResponder(
+						200,
+						`{"entities": {"Q1": {"descriptions": {"en": {"language": "en", "value": "given name"}}}, "Q7259": {"descriptions": {"en": {"language": "en", "value": "English mathematician"}}}}}`,
+					),
+				)
+
+				body := `["Kim", "Alan_Turing", "Ada_Lovelace"]`
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response struct {
+					Data []struct {
+						Result map[string]interface{} `json:"result"`
+					} `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(3))
+				Expect(response.Data).To(HaveLen(3))
+				Expect(response.Data[0].Result["data"]).To(HaveKeyWithValue("short_description", "given name"))
+				Expect(response.Data[1].Result["message"]).To(Equal("No short description found for this article."))
+				Expect(response.Data[2].Result["data"]).To(HaveKeyWithValue("short_description", "English mathematician"))
+			})
+		})
+
+		Context("when the wikitext of every article does not fit in one response", func() {
+			It("should continue the query for the revisions that were left out", func() {
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Alan_Turing|Ada_Lovelace&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"continue": {"rvcontinue": "974|1165448390", "continue": "||description|pageprops"},
+							"query": {
+								"normalized": [
+									{"fromencoded": false, "from": "Alan_Turing", "to": "Alan Turing"},
+									{"fromencoded": false, "from": "Ada_Lovelace", "to": "Ada Lovelace"}
+								],
+								"pages": [
+									{"pageid": 1208, "ns": 0, "title": "Alan Turing", "revisions": [{"content": "{{Short description|English computer scientist}}"}], "description": "English mathematician", "descriptionsource": "local"},
+									{"pageid": 974, "ns": 0, "title": "Ada Lovelace", "description": "mathematician", "descriptionsource": "central"}
+								]
+							}
+						}`,
+					),
+				)
+				httpmock.RegisterResponderWithQuery(
+					"GET",
+					"https://en.wikipedia.org/w/api.php",
+					"action=query&prop=revisions|description|pageprops&titles=Alan_Turing|Ada_Lovelace&redirects=1&formatversion=2&format=json&rvprop=content&descprefersource=local&ppprop=wikibase_item&rvcontinue=974|1165448390&continue=||description|pageprops&maxlag=5",
+
+					httpmock.NewStringResponder(
+						200,
+						`{
+							"batchcomplete": true,
+							"query": {
+								"normalized": [
+									{"fromencoded": false, "from": "Alan_Turing", "to": "Alan Turing"},
+									{"fromencoded": false, "from": "Ada_Lovelace", "to": "Ada Lovelace"}
+								],
+								"pages": [
+									{"pageid": 1208, "ns": 0, "title": "Alan Turing"},
+									{"pageid": 974, "ns": 0, "title": "Ada Lovelace", "revisions": [{"content": "{{Short description|English mathematician and writer}}"}]}
+								]
+							}
+						}`,
+					),
+				)
+
+				body := `["Alan_Turing", "Ada_Lovelace"]`
+				req, _ := http.NewRequest("POST", "/api/v1/search/batch", strings.NewReader(body))
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				internal.SearchBatch(c)
+				var response struct {
+					Data []struct {
+						Result map[string]interface{} `json:"result"`
+					} `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+				Expect(response.Data).To(HaveLen(2))
+				Expect(response.Data[0].Result["data"]).To(HaveKeyWithValue("short_description", "English computer scientist"))
+				Expect(response.Data[1].Result["data"]).To(HaveKeyWithValue("short_description", "English mathematician and writer"))
+				Expect(response.Data[1].Result["data"]).To(HaveKeyWithValue("source", "wikitext"))
+			})
 		})
 	})
 
@@ -25,7 +25,7 @@ const docTemplate = `{
     "paths": {
         "/api/v1": {
             "get": {
-                "description": "Check if the API is operational.",
+                "description": "Check if the API is operational. The status is degraded while the circuit breaker around the wikipedia API is open, in which case lookups fail fast with a 503. While the server is shutting down, the status is shutting_down and a 503 is returned so that load balancers stop routing requests to it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -40,18 +40,35 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal.CheckHealthResponse"
                         }
                     },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/internal.InternalServerErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.CheckHealthResponse"
+                        }
                     }
                 }
             }
         },
         "/api/v1/search": {
             "get": {
-                "description": "Search for a short description of a person, place, or thing.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Search for a short description of a person, place, or thing. Redirects are followed and the canonical title of the article is returned along with the redirects that were followed. The description is taken from the short description template of the article, falling back to the page description and then to the Wikidata description; the source field tells which one was used.",
                 "consumes": [
                     "application/json"
                 ],
@@ -66,6 +83,18 @@ const docTemplate = `{
                         "name": "query",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The language edition of Wikipedia to search, e.g. de. Defaults to en.",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "If the article is missing, run a full-text search and return the best matching titles and a spelling suggestion.",
+                        "name": "did_you_mean",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -73,6 +102,211 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/internal.SuccessResponse"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT if the response was served from the cache, MISS otherwise."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.InternalServerErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ServiceUnavailableErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "The number of seconds after which the wikipedia API may be called again."
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/internal.GatewayTimeoutErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/search/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Search for the short descriptions of up to 500 titles in a single request. The response contains one result per title, in request order. A title that is not a valid page title gets an error result with code 400 and does not fail the other titles.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Search for the short descriptions of many people, places, or things at once.",
+                "parameters": [
+                    {
+                        "description": "The names of the people, places, or things you want to search for.",
+                        "name": "titles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The language edition of Wikipedia to search, e.g. de. Defaults to en.",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.InternalServerErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ServiceUnavailableErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "The number of seconds after which the wikipedia API may be called again."
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/internal.GatewayTimeoutErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/suggest": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Suggest Wikipedia articles whose titles start with a prefix, ranked by relevance, together with their short descriptions. Meant for building a typeahead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Suggest people, places, or things whose names start with a prefix.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The beginning of the name of the person, place, or thing you are looking for.",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The language edition of Wikipedia to search, e.g. de. Defaults to en.",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The maximum number of suggestions, at most 50. Defaults to 10.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal.SuggestResponse"
                         }
                     },
                     "400": {
@@ -81,22 +315,266 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/internal.InternalServerErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ServiceUnavailableErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "The number of seconds after which the wikipedia API may be called again."
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/internal.GatewayTimeoutErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/summary": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the plain-text lead section of a Wikipedia article, limited to a number of sentences or characters. Without either parameter the summary is limited to the largest number of sentences the server allows. Redirects are followed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the summary of a person, place, or thing.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name of the person, place, or thing you want the summary of.",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The language edition of Wikipedia to search, e.g. de. Defaults to en.",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The maximum number of sentences of the summary.",
+                        "name": "sentences",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The approximate maximum number of characters of the summary.",
+                        "name": "chars",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal.SummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/internal.InternalServerErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ServiceUnavailableErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "string",
+                                "description": "The number of seconds after which the wikipedia API may be called again."
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/internal.GatewayTimeoutErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Check if the process is alive and able to answer requests at all. It does not depend on the wikipedia API, so a failing liveness check means the process should be restarted.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check if the process is alive.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal.LivenessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Get the metrics of the server in the Prometheus text format: HTTP requests by route and status, calls to the wikipedia API and their errors, the cache hit ratio, lookup outcomes and the circuit breaker state.",
+                "produces": [
+                    "text/plain"
+                ],
+                "summary": "Get the metrics of the server.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Check if the server is able to serve requests, with the status and latency of every check. The upstream check calls the siteinfo module of the wikipedia API and its result is reused for a while, in which case cached is set. The server is not ready only while it is shutting down or while the configuration is invalid. An open circuit breaker or an unreachable wikipedia API is reported as a warning, since every instance shares the wikipedia API and answers requests with a 503 and a Retry-After while it is down.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check if the server is able to serve requests.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/internal.ReadinessResponse"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "internal.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.BatchResult"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "internal.BatchResult": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string",
+                    "example": "Yoshua_Bengio"
+                },
+                "result": {}
+            }
+        },
         "internal.CheckHealthResponse": {
             "type": "object",
             "properties": {
+                "circuit_breaker": {
+                    "type": "string",
+                    "enum": [
+                        "closed",
+                        "open",
+                        "half-open"
+                    ],
+                    "example": "closed"
+                },
                 "status": {
                     "type": "string",
+                    "enum": [
+                        "operational",
+                        "degraded",
+                        "shutting_down"
+                    ],
                     "example": "operational"
                 }
             }
@@ -104,9 +582,36 @@ const docTemplate = `{
         "internal.Data": {
             "type": "object",
             "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "page_id": {
+                    "type": "integer",
+                    "example": 3434750
+                },
+                "redirects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.Redirect"
+                    }
+                },
                 "short_description": {
                     "type": "string",
                     "example": "A short description of the person, place, or thing you searched for."
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "wikitext",
+                        "description",
+                        "wikidata"
+                    ],
+                    "example": "wikitext"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "United States"
                 }
             }
         },
@@ -125,6 +630,38 @@ const docTemplate = `{
                 }
             }
         },
+        "internal.GatewayTimeoutError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 504
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "The wikipedia API did not respond in time. Please try again later."
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"
+                }
+            }
+        },
+        "internal.GatewayTimeoutErrorResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.GatewayTimeoutError"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "error"
+                }
+            }
+        },
         "internal.HTTPError": {
             "type": "object",
             "properties": {
@@ -139,6 +676,42 @@ const docTemplate = `{
                 "request_id": {
                     "type": "string",
                     "example": "f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "illegal_character"
+                },
+                "upstream_code": {
+                    "description": "UpstreamCode is the error code reported by the wikipedia API, if any.",
+                    "type": "string",
+                    "example": "invalidtitle"
+                }
+            }
+        },
+        "internal.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is set when the result of an earlier check was reused.",
+                    "type": "boolean",
+                    "example": true
+                },
+                "latency_ms": {
+                    "type": "number",
+                    "example": 84.2
+                },
+                "message": {
+                    "type": "string",
+                    "example": "MediaWiki 1.41.0-wmf.1"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pass",
+                        "warn",
+                        "fail"
+                    ],
+                    "example": "pass"
                 }
             }
         },
@@ -174,6 +747,83 @@ const docTemplate = `{
                 }
             }
         },
+        "internal.LivenessResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "alive"
+                }
+            }
+        },
+        "internal.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/internal.HealthCheck"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ready",
+                        "not_ready"
+                    ],
+                    "example": "ready"
+                }
+            }
+        },
+        "internal.Redirect": {
+            "type": "object",
+            "properties": {
+                "fragment": {
+                    "type": "string",
+                    "example": ""
+                },
+                "from": {
+                    "type": "string",
+                    "example": "USA"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "United States"
+                }
+            }
+        },
+        "internal.ServiceUnavailableError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "The wikipedia API is currently unavailable. Please try again later."
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"
+                }
+            }
+        },
+        "internal.ServiceUnavailableErrorResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.ServiceUnavailableError"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "error"
+                }
+            }
+        },
         "internal.SuccessResponse": {
             "type": "object",
             "properties": {
@@ -185,6 +835,92 @@ const docTemplate = `{
                     "example": "success"
                 }
             }
+        },
+        "internal.SuggestResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.Suggestion"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "internal.Suggestion": {
+            "type": "object",
+            "properties": {
+                "page_id": {
+                    "type": "integer",
+                    "example": 47749536
+                },
+                "short_description": {
+                    "type": "string",
+                    "example": "Canadian computer scientist"
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "description",
+                        "wikidata"
+                    ],
+                    "example": "description"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Yoshua Bengio"
+                }
+            }
+        },
+        "internal.SummaryData": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "page_id": {
+                    "type": "integer",
+                    "example": 47749536
+                },
+                "redirects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal.Redirect"
+                    }
+                },
+                "summary": {
+                    "type": "string",
+                    "example": "Yoshua Bengio is a Canadian computer scientist, most noted for his work on artificial neural networks and deep learning."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Yoshua Bengio"
+                }
+            }
+        },
+        "internal.SummaryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal.SummaryData"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
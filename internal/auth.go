@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+)
+
+// APIKeyContextKey is the gin context key holding the name of the API key a
+// request was authenticated with.
+const APIKeyContextKey = "apiKey"
+
+// quotas counts the requests made with every API key during the current UTC
+// day. It is safe for concurrent use.
+type quotas struct {
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+}
+
+func newQuotas() *quotas {
+	return &quotas{counts: make(map[string]int)}
+}
+
+// Take counts a request made with key at now and reports whether the daily
+// quota of the key allowed it.
+func (q *quotas) Take(key *config.APIKey, now time.Time) bool {
+	if key.DailyQuota == 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if day := now.UTC().Format("2006-01-02"); day != q.day {
+		q.day = day
+		q.counts = make(map[string]int)
+	}
+
+	if q.counts[key.Name] >= key.DailyQuota {
+		return false
+	}
+
+	q.counts[key.Name]++
+
+	return true
+}
+
+// authFailureContextKey is the gin context key holding why a request could
+// not be authenticated, until Authorize rejects it.
+const authFailureContextKey = "authFailure"
+
+// apiKeyConfigContextKey is the gin context key holding the *config.APIKey a
+// request was authenticated with.
+const apiKeyConfigContextKey = "apiKeyConfig"
+
+// Authenticate identifies the API key of a request, from the X-API-Key header
+// or the api_key query parameter, and stores its name in the gin context under
+// APIKeyContextKey. It rejects nothing itself, so that the rate limiter can
+// limit requests without a valid key by IP address before Authorize rejects
+// them. Authorize must follow it.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(apiKeys) == 0 || isPublicRoute(c.FullPath()) {
+			c.Next()
+
+			return
+		}
+
+		secret := c.GetHeader("X-API-Key")
+		if secret == "" {
+			secret = c.Query("api_key")
+		}
+
+		if secret == "" {
+			c.Set(authFailureContextKey, "An API key is required. Please pass it in the X-API-Key header or the api_key query parameter.")
+			c.Next()
+
+			return
+		}
+
+		hash := sha256.Sum256([]byte(secret))
+		key, ok := apiKeys[hex.EncodeToString(hash[:])]
+		if !ok {
+			c.Set(authFailureContextKey, "Invalid API key.")
+			c.Next()
+
+			return
+		}
+
+		c.Set(APIKeyContextKey, key.Name)
+		c.Set(apiKeyConfigContextKey, key)
+		c.Next()
+	}
+}
+
+// Authorize requires requests to carry one of the configured API keys, unless
+// the route is one of config.Config.PublicRoutes or no keys are configured at
+// all. It responds with a 401 if Authenticate found the key missing or
+// unknown and with a 403 if the key may not access the route or has used up
+// its daily quota. It follows the rate limiter, so that requests the limiter
+// rejects do not count against the quota.
+func Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if failure := c.GetString(authFailureContextKey); failure != "" {
+			HttpErrorHandler(c, http.StatusUnauthorized, failure)
+			c.Abort()
+
+			return
+		}
+
+		value, ok := c.Get(apiKeyConfigContextKey)
+		if !ok {
+			c.Next()
+
+			return
+		}
+
+		key := value.(*config.APIKey)
+		route := c.FullPath()
+
+		if !key.Allows(route) {
+			HttpErrorHandler(c, http.StatusForbidden, fmt.Sprintf("The API key '%s' is not allowed to access %s.", key.Name, route))
+			c.Abort()
+
+			return
+		}
+
+		if !apiKeyQuotas.Take(key, time.Now()) {
+			HttpErrorHandler(c, http.StatusForbidden, fmt.Sprintf("The API key '%s' has used up its daily quota of %d requests. The quota resets at midnight UTC.", key.Name, key.DailyQuota))
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isPublicRoute(route string) bool {
+	for _, public := range settings.PublicRoutes {
+		if public == route {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// lookup is the outcome of a short description lookup. err is nil,
+// wikipedia.ErrMissing or wikipedia.ErrNoDescription.
+type lookup struct {
+	result *wikipedia.Result
+	err    error
+}
+
+// cache is a bounded, least recently used cache of lookups in which every
+// entry expires after its own TTL. It is safe for concurrent use.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	value   lookup
+	expires time.Time
+}
+
+// newCache returns a cache holding at most size entries. A size of zero
+// returns a cache that never stores anything.
+func newCache(size int) *cache {
+	return &cache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+		now:   time.Now,
+	}
+}
+
+// Get returns the unexpired value stored for key.
+func (c *cache) Get(key string) (lookup, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return lookup{}, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+
+		return lookup{}, false
+	}
+
+	c.ll.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// Set stores value for key for the duration of ttl, evicting the least
+// recently used entry if the cache is full.
+func (c *cache) Set(key string, value lookup, ttl time.Duration) {
+	if c.size <= 0 || ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.value = value
+		entry.expires = expires
+		c.ll.MoveToFront(element)
+
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+
+	if c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Len returns the number of entries currently held, including expired
+// entries that have not been evicted yet.
+func (c *cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *cache) remove(element *list.Element) {
+	c.ll.Remove(element)
+	delete(c.items, element.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,396 @@
+// Package config defines the settings of the server and loads them from
+// defaults, a YAML file, environment variables and command line flags, in
+// that order.
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// Config holds the settings of the server. Every setting has a key in the
+// configuration file, given by its yaml tag, and most can be overridden by the
+// environment variable in its env tag or by the command line flag of the same
+// name in lower case with dashes, e.g. --upstream-timeout.
+type Config struct {
+	// Port is the TCP port the server listens on.
+	Port int `yaml:"port" env:"PORT"`
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are the
+	// timeouts of the HTTP server, see http.Server. Zero disables a timeout.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT"`
+
+	// MaxHeaderBytes is the largest size of the request headers accepted by
+	// the HTTP server.
+	MaxHeaderBytes int `yaml:"max_header_bytes" env:"MAX_HEADER_BYTES"`
+
+	// ShutdownDelay is how long the server keeps serving requests after
+	// being asked to stop, while it reports itself as not ready so that load
+	// balancers stop routing requests to it.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+
+	// ShutdownTimeout is how long in-flight requests are given to complete
+	// once the server stops accepting new connections.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// WikipediaAPIURL is the MediaWiki action API endpoint. A {lang}
+	// placeholder is replaced by the requested language.
+	WikipediaAPIURL string `yaml:"wikipedia_api_url" env:"WIKIPEDIA_API_URL" redact:"url"`
+
+	// WikidataAPIURL is the Wikidata action API endpoint used to look up
+	// descriptions of articles without a short description.
+	WikidataAPIURL string `yaml:"wikidata_api_url" env:"WIKIDATA_API_URL" redact:"url"`
+
+	// Languages are the Wikipedia language editions that can be requested
+	// with the lang query parameter. The first one is used when no language
+	// is requested.
+	Languages []string `yaml:"languages" env:"ALLOWED_LANGUAGES"`
+
+	// UserAgent is sent with every upstream request. Wikimedia asks for it
+	// to name the service and say how to contact its operators.
+	UserAgent string `yaml:"user_agent" env:"USER_AGENT"`
+
+	// MaxLag is the maxlag parameter sent with every upstream request, in
+	// seconds. Zero leaves it out.
+	MaxLag int `yaml:"maxlag" env:"MAXLAG"`
+
+	// UpstreamMaxConcurrency is the maximum number of upstream requests in
+	// flight at once. Zero removes the limit.
+	UpstreamMaxConcurrency int `yaml:"upstream_max_concurrency" env:"UPSTREAM_MAX_CONCURRENCY"`
+
+	// UpstreamConnectTimeout limits establishing a connection to MediaWiki,
+	// including the TLS handshake.
+	UpstreamConnectTimeout time.Duration `yaml:"upstream_connect_timeout" env:"UPSTREAM_CONNECT_TIMEOUT"`
+
+	// UpstreamReadTimeout limits waiting for the response headers of
+	// MediaWiki once the request has been sent.
+	UpstreamReadTimeout time.Duration `yaml:"upstream_read_timeout" env:"UPSTREAM_READ_TIMEOUT"`
+
+	// UpstreamTimeout limits all upstream calls made for a single request.
+	// Zero disables the limit.
+	UpstreamTimeout time.Duration `yaml:"upstream_timeout" env:"UPSTREAM_TIMEOUT"`
+
+	// RetryMaxAttempts, RetryBaseDelay, RetryMaxDelay and
+	// RetryMaxRetryAfter configure the retries of upstream requests that
+	// failed with a transient error.
+	RetryMaxAttempts   int           `yaml:"retry_max_attempts" env:"RETRY_MAX_ATTEMPTS"`
+	RetryBaseDelay     time.Duration `yaml:"retry_base_delay" env:"RETRY_BASE_DELAY"`
+	RetryMaxDelay      time.Duration `yaml:"retry_max_delay" env:"RETRY_MAX_DELAY"`
+	RetryMaxRetryAfter time.Duration `yaml:"retry_max_retry_after" env:"RETRY_MAX_RETRY_AFTER"`
+
+	// BreakerFailureThreshold is the number of consecutive upstream failures
+	// after which the circuit breaker opens. Zero disables the breaker.
+	BreakerFailureThreshold int `yaml:"breaker_failure_threshold" env:"BREAKER_FAILURE_THRESHOLD"`
+
+	// BreakerCooldown is how long the circuit breaker stays open before it
+	// lets a probe request through.
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown" env:"BREAKER_COOLDOWN"`
+
+	// DidYouMeanResults is the number of matching titles returned with a
+	// missing article when the did_you_mean parameter is set.
+	DidYouMeanResults int `yaml:"did_you_mean_results" env:"DID_YOU_MEAN_RESULTS"`
+
+	// SummaryMaxSentences and SummaryMaxChars are the largest lengths that
+	// can be requested from the summary endpoint. Summaries requested without
+	// a length are limited to SummaryMaxSentences.
+	SummaryMaxSentences int `yaml:"summary_max_sentences" env:"SUMMARY_MAX_SENTENCES"`
+	SummaryMaxChars     int `yaml:"summary_max_chars" env:"SUMMARY_MAX_CHARS"`
+
+	// CORS is the CORS policy of every route without an override in
+	// CORSOverrides.
+	CORS CORSPolicy `yaml:"cors"`
+
+	// CORSOverrides change the CORS policy for the routes under a path
+	// prefix, keyed by the prefix.
+	CORSOverrides map[string]CORSOverride `yaml:"cors_overrides"`
+
+	// APIKeys are the keys clients authenticate with. No keys disables
+	// authentication.
+	APIKeys []APIKey `yaml:"api_keys"`
+
+	// APIKeysFile is a YAML file with more API keys under a top-level keys
+	// list, so that they can be kept apart from the other settings.
+	APIKeysFile string `yaml:"api_keys_file" env:"API_KEYS_FILE"`
+
+	// PublicRoutes are the routes that can be accessed without an API key,
+	// e.g. for health checks.
+	PublicRoutes []string `yaml:"public_routes" env:"PUBLIC_ROUTES"`
+
+	// RateLimit is the number of requests a client can make to a route per
+	// period, unless the route has a limit of its own in RouteRateLimits.
+	RateLimit RateLimit `yaml:"rate_limit" env:"RATE_LIMIT"`
+
+	// RouteRateLimits are the rate limits of routes that differ from
+	// RateLimit, keyed by route, e.g. /api/v1/search/batch.
+	RouteRateLimits map[string]RateLimit `yaml:"rate_limit_routes" env:"RATE_LIMIT_ROUTES"`
+
+	// TrustedProxies are the IP addresses and CIDR ranges of the proxies in
+	// front of the server. Only requests coming from them may name the
+	// client in the X-Forwarded-For and X-Real-IP headers, others are
+	// identified by the address they connect from.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+
+	// TrustedPlatformHeader is a header the hosting platform sets to the IP
+	// address of the client, e.g. CF-Connecting-IP behind Cloudflare. It is
+	// believed whatever the proxy, so it must only be set when clients cannot
+	// reach the server without going through the platform.
+	TrustedPlatformHeader string `yaml:"trusted_platform_header" env:"TRUSTED_PLATFORM_HEADER"`
+
+	// ReadinessProbeInterval is how long the result of the upstream check
+	// of the readiness endpoint is reused before MediaWiki is probed again.
+	ReadinessProbeInterval time.Duration `yaml:"readiness_probe_interval" env:"READINESS_PROBE_INTERVAL"`
+
+	// CacheSize is the maximum number of lookups kept in memory. Zero
+	// disables caching.
+	CacheSize int `yaml:"cache_size" env:"CACHE_SIZE"`
+
+	// CacheTTL is how long a found short description is cached.
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"CACHE_TTL"`
+
+	// CacheNegativeTTL is how long a missing article or an article without
+	// a short description is cached.
+	CacheNegativeTTL time.Duration `yaml:"cache_negative_ttl" env:"CACHE_NEGATIVE_TTL"`
+
+	// TracingExporter is where OpenTelemetry spans are sent: none, stdout or
+	// otlp.
+	TracingExporter string `yaml:"tracing_exporter" env:"TRACING_EXPORTER"`
+
+	// OTLPEndpoint is the base URL of the OTLP/HTTP collector spans are sent
+	// to by the otlp exporter, e.g. http://localhost:4318.
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" redact:"url"`
+
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	return Config{
+		Port:                    3000,
+		ReadHeaderTimeout:       5 * time.Second,
+		ReadTimeout:             15 * time.Second,
+		WriteTimeout:            30 * time.Second,
+		IdleTimeout:             2 * time.Minute,
+		MaxHeaderBytes:          64 << 10,
+		ShutdownDelay:           5 * time.Second,
+		ShutdownTimeout:         20 * time.Second,
+		WikipediaAPIURL:         wikipedia.DefaultBaseURL,
+		WikidataAPIURL:          wikipedia.DefaultWikidataURL,
+		Languages:               []string{wikipedia.DefaultLanguage},
+		UserAgent:               wikipedia.DefaultUserAgent,
+		MaxLag:                  5,
+		UpstreamMaxConcurrency:  1,
+		UpstreamConnectTimeout:  5 * time.Second,
+		UpstreamReadTimeout:     10 * time.Second,
+		UpstreamTimeout:         15 * time.Second,
+		RetryMaxAttempts:        wikipedia.DefaultRetryPolicy.MaxAttempts,
+		RetryBaseDelay:          wikipedia.DefaultRetryPolicy.BaseDelay,
+		RetryMaxDelay:           wikipedia.DefaultRetryPolicy.MaxDelay,
+		RetryMaxRetryAfter:      wikipedia.DefaultRetryPolicy.MaxRetryAfter,
+		BreakerFailureThreshold: 5,
+		BreakerCooldown:         30 * time.Second,
+		DidYouMeanResults:       5,
+		SummaryMaxSentences:     10,
+		SummaryMaxChars:         1200,
+		CORS: CORSPolicy{
+			AllowOrigins: []string{"http://wikipedia.youssefsobhy.com", "https://wikipedia.youssefsobhy.com"},
+			AllowMethods: []string{"GET"},
+			AllowHeaders: []string{"Origin", "Content-Length", "Content-Type", "X-API-Key"},
+			MaxAge:       12 * time.Hour,
+		},
+		CORSOverrides: map[string]CORSOverride{
+			"/api/v1/search/batch": {AllowMethods: []string{"POST"}},
+		},
+		PublicRoutes: []string{"/api/v1", "/api/v1/docs", "/api/v1/docs/*any"},
+		RateLimit:    RateLimit{Requests: 60, Period: time.Minute},
+		RouteRateLimits: map[string]RateLimit{
+			"/api/v1/search/batch": {Requests: 10, Period: time.Minute},
+		},
+		TrustedProxies:         []string{},
+		ReadinessProbeInterval: 30 * time.Second,
+		CacheSize:              10000,
+		CacheTTL:               24 * time.Hour,
+		CacheNegativeTTL:       10 * time.Minute,
+		TracingExporter:        "none",
+		OTLPEndpoint:           "http://localhost:4318",
+		ServiceName:            "wikipedia-api",
+	}
+}
+
+// RateLimit allows a client Requests requests per Period, in bursts of up to
+// Requests requests. A zero RateLimit allows any number of requests. It is
+// written as requests/period, e.g. 60/1m, or 0 for no limit.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Disabled reports whether the limit allows any number of requests.
+func (l RateLimit) Disabled() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+func (l RateLimit) MarshalText() ([]byte, error) {
+	if l.Disabled() {
+		return []byte("0"), nil
+	}
+
+	return []byte(fmt.Sprintf("%d/%s", l.Requests, l.Period)), nil
+}
+
+func (l *RateLimit) UnmarshalText(text []byte) error {
+	value := string(text)
+	if value == "0" {
+		*l = RateLimit{}
+
+		return nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return fmt.Errorf("rate limit must look like 60/1m, got %q", value)
+	}
+
+	var (
+		limit RateLimit
+		err   error
+	)
+
+	limit.Requests, err = strconv.Atoi(requests)
+	if err != nil || limit.Requests < 0 {
+		return fmt.Errorf("rate limit must start with a non-negative number of requests, got %q", value)
+	}
+
+	limit.Period, err = time.ParseDuration(period)
+	if err != nil || limit.Period <= 0 {
+		return fmt.Errorf("rate limit must end with a positive period such as 1m, got %q", value)
+	}
+
+	*l = limit
+
+	return nil
+}
+
+// CORSPolicy controls which cross-origin requests browsers may make.
+type CORSPolicy struct {
+	// AllowOrigins are the origins allowed to make requests, e.g.
+	// https://example.com. An origin may contain a single * wildcard, as in
+	// https://*.example.com, and * on its own allows every origin.
+	AllowOrigins []string `yaml:"allow_origins" env:"CORS_ALLOW_ORIGINS"`
+
+	// AllowMethods are the HTTP methods cross-origin requests may use.
+	AllowMethods []string `yaml:"allow_methods" env:"CORS_ALLOW_METHODS"`
+
+	// AllowHeaders are the request headers cross-origin requests may set.
+	AllowHeaders []string `yaml:"allow_headers" env:"CORS_ALLOW_HEADERS"`
+
+	// AllowCredentials allows cross-origin requests to include cookies and
+	// HTTP authentication. It cannot be combined with the * origin.
+	AllowCredentials bool `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+
+	// MaxAge is how long browsers may cache the result of a preflight
+	// request.
+	MaxAge time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// Validate returns an error if the policy cannot be enforced.
+func (p CORSPolicy) Validate() error {
+	if len(p.AllowOrigins) == 0 {
+		return fmt.Errorf("at least one origin must be allowed")
+	}
+
+	for _, origin := range p.AllowOrigins {
+		if origin == "*" {
+			if p.AllowCredentials {
+				return fmt.Errorf("credentials cannot be allowed for every origin")
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("origin %q must start with http:// or https://", origin)
+		}
+
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("origin %q may contain only one * wildcard", origin)
+		}
+	}
+
+	return nil
+}
+
+// CORSOverride changes the CORS policy for the routes under a path prefix,
+// e.g. to allow POST on /api/v1/search/batch. Unset fields keep the value of
+// the default policy.
+type CORSOverride struct {
+	AllowOrigins     []string      `yaml:"allow_origins,omitempty"`
+	AllowMethods     []string      `yaml:"allow_methods,omitempty"`
+	AllowHeaders     []string      `yaml:"allow_headers,omitempty"`
+	AllowCredentials *bool         `yaml:"allow_credentials,omitempty"`
+	MaxAge           time.Duration `yaml:"max_age,omitempty"`
+}
+
+// Apply returns policy changed by the set fields of o.
+func (o CORSOverride) Apply(policy CORSPolicy) CORSPolicy {
+	if o.AllowOrigins != nil {
+		policy.AllowOrigins = o.AllowOrigins
+	}
+
+	if o.AllowMethods != nil {
+		policy.AllowMethods = o.AllowMethods
+	}
+
+	if o.AllowHeaders != nil {
+		policy.AllowHeaders = o.AllowHeaders
+	}
+
+	if o.AllowCredentials != nil {
+		policy.AllowCredentials = *o.AllowCredentials
+	}
+
+	if o.MaxAge != 0 {
+		policy.MaxAge = o.MaxAge
+	}
+
+	return policy
+}
+
+// APIKey is a key that clients pass to authenticate with the API.
+type APIKey struct {
+	// Name identifies the key, e.g. the team it was handed out to.
+	Name string `yaml:"name"`
+
+	// SHA256 is the hex-encoded SHA-256 hash of the secret key.
+	SHA256 string `yaml:"sha256" redact:"true"`
+
+	// Routes are the routes the key may access, e.g. /api/v1/search. An
+	// empty list allows every route.
+	Routes []string `yaml:"routes"`
+
+	// DailyQuota is the number of requests the key may make per UTC day.
+	// Zero allows any number.
+	DailyQuota int `yaml:"daily_quota"`
+}
+
+// Allows reports whether the key may access route.
+func (k APIKey) Allows(route string) bool {
+	if len(k.Routes) == 0 {
+		return true
+	}
+
+	for _, allowed := range k.Routes {
+		if allowed == route {
+			return true
+		}
+	}
+
+	return false
+}
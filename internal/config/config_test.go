@@ -0,0 +1,162 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+)
+
+// env returns a lookupEnv function backed by vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+// writeFile writes content to a file in a temporary directory and returns
+// its path.
+func writeFile(name, content string) string {
+	path := filepath.Join(GinkgoT().TempDir(), name)
+	Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	return path
+}
+
+var _ = Describe("Load", func() {
+	It("returns the defaults when nothing is overridden", func() {
+		cfg, opts, err := config.Load(nil, env(nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(config.Options{}))
+		Expect(cfg).To(Equal(config.Default()))
+	})
+
+	It("applies the file, then the environment, then the flags", func() {
+		file := writeFile("config.yaml", `
+port: 4000
+upstream_timeout: 20s
+cache_size: 10
+rate_limit: 30/1m
+languages: [en, de]
+rate_limit_routes:
+  /api/v1/search: 5/1s
+`)
+
+		cfg, opts, err := config.Load(
+			[]string{"--config", file, "--port", "5000"},
+			env(map[string]string{"PORT": "4500", "CACHE_SIZE": "20"}),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts.File).To(Equal(file))
+
+		Expect(cfg.Port).To(Equal(5000))
+		Expect(cfg.CacheSize).To(Equal(20))
+		Expect(cfg.UpstreamTimeout).To(Equal(20 * time.Second))
+		Expect(cfg.RateLimit).To(Equal(config.RateLimit{Requests: 30, Period: time.Minute}))
+		Expect(cfg.Languages).To(Equal([]string{"en", "de"}))
+		Expect(cfg.RouteRateLimits).To(HaveKeyWithValue("/api/v1/search", config.RateLimit{Requests: 5, Period: time.Second}))
+		Expect(cfg.RouteRateLimits).To(HaveKey("/api/v1/search/batch"))
+		Expect(cfg.CORS).To(Equal(config.Default().CORS))
+	})
+
+	It("reads the file named by CONFIG_FILE", func() {
+		file := writeFile("config.yaml", "cors:\n  max_age: 1h\n")
+
+		cfg, _, err := config.Load(nil, env(map[string]string{"CONFIG_FILE": file}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.CORS.MaxAge).To(Equal(time.Hour))
+		Expect(cfg.CORS.AllowMethods).To(Equal([]string{"GET"}))
+	})
+
+	It("rejects unknown settings in the file", func() {
+		file := writeFile("config.yaml", "prot: 4000\n")
+
+		_, _, err := config.Load([]string{"--config", file}, env(nil))
+		Expect(err).To(MatchError(ContainSubstring("field prot not found")))
+	})
+
+	It("names the source of a value that cannot be parsed", func() {
+		_, _, err := config.Load(nil, env(map[string]string{"UPSTREAM_TIMEOUT": "soon"}))
+		Expect(err).To(MatchError(`UPSTREAM_TIMEOUT must be a duration such as 10m or 24h, got "soon"`))
+
+		_, _, err = config.Load([]string{"--cache-size", "many"}, env(nil))
+		Expect(err).To(MatchError(ContainSubstring("--cache-size")))
+	})
+
+	It("lists every problem of an invalid configuration", func() {
+		_, _, err := config.Load(
+			[]string{"--port", "0", "--wikipedia-api-url", "ftp://example.com"},
+			env(map[string]string{"CACHE_TTL": "-1m", "USER_AGENT": " ", "TRACING_EXPORTER": "jaeger", "SUMMARY_MAX_SENTENCES": "11", "SUMMARY_MAX_CHARS": "5000"}),
+		)
+
+		var validationErr *config.ValidationError
+		Expect(err).To(BeAssignableToTypeOf(validationErr))
+		Expect(err.Error()).To(HavePrefix("invalid configuration:\n"))
+		Expect(err.(*config.ValidationError).Problems).To(HaveLen(7))
+		Expect(err.Error()).To(ContainSubstring("port"))
+		Expect(err.Error()).To(ContainSubstring("wikipedia_api_url"))
+		Expect(err.Error()).To(ContainSubstring("cache_ttl"))
+		Expect(err.Error()).To(ContainSubstring("user_agent"))
+		Expect(err.Error()).To(ContainSubstring("tracing_exporter"))
+		Expect(err.Error()).To(ContainSubstring("summary_max_sentences must be between 1 and 10, got 11"))
+		Expect(err.Error()).To(ContainSubstring("summary_max_chars must be between 1 and 1200, got 5000"))
+	})
+
+	It("appends the keys of the API keys file", func() {
+		keys := writeFile("keys.yaml", `
+keys:
+  - name: acme
+    sha256: 1EC1C26B50D5D3C58D9583181AF8076655FE00756BF7285940BA3670F99FCBA0
+`)
+
+		cfg, _, err := config.Load(nil, env(map[string]string{"API_KEYS_FILE": keys}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.APIKeys).To(Equal([]config.APIKey{{
+			Name:   "acme",
+			SHA256: "1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+		}}))
+	})
+})
+
+var _ = Describe("WriteRedacted", func() {
+	It("redacts secrets and can be read back", func() {
+		cfg := config.Default()
+		cfg.WikipediaAPIURL = "https://bot:hunter2@{lang}.wikipedia.org/w/api.php"
+		cfg.APIKeys = []config.APIKey{{
+			Name:   "acme",
+			SHA256: "1ec1c26b50d5d3c58d9583181af8076655fe00756bf7285940ba3670f99fcba0",
+		}}
+
+		var out bytes.Buffer
+		Expect(cfg.WriteRedacted(&out)).To(Succeed())
+		Expect(out.String()).NotTo(ContainSubstring("hunter2"))
+		Expect(out.String()).NotTo(ContainSubstring("1ec1c26b"))
+		Expect(out.String()).To(ContainSubstring("{lang}.wikipedia.org"))
+		Expect(out.String()).To(ContainSubstring("upstream_timeout: 15s"))
+
+		cfg.WikipediaAPIURL = config.Default().WikipediaAPIURL
+		cfg.APIKeys = nil
+		out.Reset()
+		Expect(cfg.WriteRedacted(&out)).To(Succeed())
+
+		file := writeFile("config.yaml", out.String())
+		loaded, _, err := config.Load([]string{"--config", file}, env(nil))
+		Expect(err).NotTo(HaveOccurred())
+
+		var reloaded bytes.Buffer
+		Expect(loaded.WriteRedacted(&reloaded)).To(Succeed())
+		Expect(reloaded.String()).To(Equal(out.String()))
+	})
+})
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
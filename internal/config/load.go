@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options are the command line options that are not settings.
+type Options struct {
+	// File is the configuration file, set with --config or CONFIG_FILE.
+	File string
+
+	// PrintConfig asks for the effective configuration to be printed
+	// instead of starting the server, set with --print-config.
+	PrintConfig bool
+}
+
+// Load returns the default configuration overridden by the configuration
+// file, then by the environment variables found with lookupEnv and then by the
+// command line flags in args. The result is validated. If args ask for help,
+// the usage is written to stderr and flag.ErrHelp is returned.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, Options, error) {
+	cfg := Default()
+	settings := settingsOf(&cfg)
+
+	var (
+		opts  Options
+		flags []func() error
+	)
+
+	fs := flag.NewFlagSet("wikipedia-api", flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", "", "read settings from this YAML `file`, also set with CONFIG_FILE")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, "overrides "+s.env, func(value string) error {
+			// Flags are applied last, once the file and the environment
+			// have been read.
+			flags = append(flags, func() error {
+				return s.set(value, "--"+s.flag)
+			})
+
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, opts, err
+	}
+
+	if fs.NArg() > 0 {
+		return cfg, opts, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if opts.File == "" {
+		opts.File, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	if opts.File != "" {
+		if err := loadFile(&cfg, opts.File); err != nil {
+			return cfg, opts, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := s.set(value, s.env); err != nil {
+				return cfg, opts, err
+			}
+		}
+	}
+
+	for _, apply := range flags {
+		if err := apply(); err != nil {
+			return cfg, opts, err
+		}
+	}
+
+	if cfg.APIKeysFile != "" {
+		keys, err := loadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return cfg, opts, err
+		}
+
+		cfg.APIKeys = append(cfg.APIKeys, keys...)
+	}
+
+	for i := range cfg.APIKeys {
+		cfg.APIKeys[i].SHA256 = strings.ToLower(cfg.APIKeys[i].SHA256)
+	}
+
+	return cfg, opts, cfg.Validate()
+}
+
+// loadFile overrides cfg with the settings in a YAML file. Settings missing
+// from the file keep their value, lists replace the previous list and maps are
+// merged into the previous map.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadAPIKeys reads the API keys from a YAML file with a top-level keys list.
+func loadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return file.Keys, nil
+}
+
+// setting is a field of Config that can be set from the environment and the
+// command line.
+type setting struct {
+	env   string
+	flag  string
+	value reflect.Value
+}
+
+// settingsOf returns the settings of cfg, including those of nested structs.
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if env := field.Tag.Get("env"); env != "" {
+				settings = append(settings, setting{
+					env:   env,
+					flag:  strings.ReplaceAll(strings.ToLower(env), "_", "-"),
+					value: v.Field(i),
+				})
+			} else if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+			}
+		}
+	}
+
+	walk(reflect.ValueOf(cfg).Elem())
+
+	return settings
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// set parses value into the setting. source names where the value came from
+// in error messages.
+func (s setting) set(value, source string) error {
+	v := s.value
+
+	switch {
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshalerType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 10m or 24h, got %q", source, value)
+		}
+
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", source, value)
+		}
+
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", source, value)
+		}
+
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(value)))
+	case v.Kind() == reflect.Map:
+		return s.setMap(value, source)
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %s", v.Type()))
+	}
+
+	return nil
+}
+
+// setMap merges a comma-separated list of key=value pairs into a map setting
+// whose values are encoding.TextUnmarshalers.
+func (s setting) setMap(value, source string) error {
+	merged := reflect.MakeMap(s.value.Type())
+	for _, key := range s.value.MapKeys() {
+		merged.SetMapIndex(key, s.value.MapIndex(key))
+	}
+
+	for _, pair := range splitList(value) {
+		key, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%s must be a comma-separated list of key=value pairs, got %q", source, value)
+		}
+
+		elem := reflect.New(s.value.Type().Elem())
+		if err := elem.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("%s: %s: %w", source, key, err)
+		}
+
+		merged.SetMapIndex(reflect.ValueOf(key), elem.Elem())
+	}
+
+	s.value.Set(merged)
+
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
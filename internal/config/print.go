@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of secret settings in printed configurations.
+const redacted = "REDACTED"
+
+// WriteRedacted writes cfg to w as a configuration file, with secrets such as
+// API key hashes and passwords in URLs redacted.
+func (cfg Config) WriteRedacted(w io.Writer) error {
+	node, err := encodeNode(reflect.ValueOf(cfg), "")
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// encodeNode returns the YAML node of v, keeping the order of struct fields
+// and writing durations as strings such as 10m0s so that the output can be
+// read back. redact is the redact tag of the field holding v.
+func encodeNode(v reflect.Value, redact string) (*yaml.Node, error) {
+	switch {
+	case redact == "true" && v.Kind() == reflect.String && v.String() != "":
+		return scalarNode(redacted), nil
+	case redact == "url" && v.Kind() == reflect.String:
+		return scalarNode(redactURL(v.String())), nil
+	case v.Type() == durationType:
+		return scalarNode(time.Duration(v.Int()).String()), nil
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		return scalarNode(string(text)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" || options == "omitempty" && v.Field(i).IsZero() {
+				continue
+			}
+
+			value, err := encodeNode(v.Field(i), field.Tag.Get("redact"))
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, scalarNode(name), value)
+		}
+
+		return node, nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		if len(keys) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+
+		for _, key := range keys {
+			value, err := encodeNode(v.MapIndex(key), "")
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, scalarNode(key.String()), value)
+		}
+
+		return node, nil
+	case reflect.Slice:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if v.Type().Elem().Kind() == reflect.String || v.Len() == 0 {
+			node.Style = yaml.FlowStyle
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			value, err := encodeNode(v.Index(i), redact)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, value)
+		}
+
+		return node, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+		}
+
+		return encodeNode(v.Elem(), redact)
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, fmt.Errorf("config: encoding %s: %w", v.Type(), err)
+		}
+
+		return node, nil
+	}
+}
+
+func scalarNode(value string) *yaml.Node {
+	node := &yaml.Node{}
+	node.SetString(value)
+
+	return node
+}
+
+// langPlaceholder stands in for the {lang} placeholder of API URLs, which is
+// not valid in a host name, while they are parsed.
+const langPlaceholder = "lang-placeholder"
+
+// redactURL hides the password in a URL, if it has one.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(strings.ReplaceAll(rawURL, "{lang}", langPlaceholder))
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	return strings.ReplaceAll(u.String(), langPlaceholder, "{lang}")
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// ValidationError lists everything that is wrong with a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate returns a *ValidationError if cfg cannot be used to run the
+// server.
+func (cfg Config) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	problems = append(problems, negativeSettings(reflect.ValueOf(cfg), "")...)
+
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		problem("port must be between 1 and 65535, got %d", cfg.Port)
+	}
+
+	if cfg.MaxHeaderBytes < 1 {
+		problem("max_header_bytes must be at least 1, got %d", cfg.MaxHeaderBytes)
+	}
+
+	// A response cut off by the write timeout is worse than the 504 the
+	// upstream timeout leads to.
+	if cfg.WriteTimeout > 0 && (cfg.UpstreamTimeout == 0 || cfg.WriteTimeout <= cfg.UpstreamTimeout) {
+		problem("write_timeout must be longer than upstream_timeout, got %s and %s", cfg.WriteTimeout, cfg.UpstreamTimeout)
+	}
+
+	urls := []struct{ name, url string }{
+		{"wikipedia_api_url", cfg.WikipediaAPIURL},
+		{"wikidata_api_url", cfg.WikidataAPIURL},
+	}
+
+	switch cfg.TracingExporter {
+	case "none", "stdout":
+	case "otlp":
+		urls = append(urls, struct{ name, url string }{"otlp_endpoint", cfg.OTLPEndpoint})
+	default:
+		problem("tracing_exporter must be none, stdout or otlp, got %q", cfg.TracingExporter)
+	}
+
+	if strings.TrimSpace(cfg.ServiceName) == "" {
+		problem("service_name must not be empty")
+	}
+
+	for _, setting := range urls {
+		u, err := url.Parse(strings.ReplaceAll(setting.url, "{lang}", langPlaceholder))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problem("%s must be an http or https URL, got %q", setting.name, setting.url)
+		}
+	}
+
+	if len(cfg.Languages) == 0 {
+		problem("languages must contain at least one language")
+	}
+
+	if strings.TrimSpace(cfg.UserAgent) == "" {
+		problem("user_agent must not be empty")
+	}
+
+	if cfg.DidYouMeanResults < 1 {
+		problem("did_you_mean_results must be at least 1, got %d", cfg.DidYouMeanResults)
+	}
+
+	if cfg.SummaryMaxSentences < 1 || cfg.SummaryMaxSentences > wikipedia.MaxSummarySentences {
+		problem("summary_max_sentences must be between 1 and %d, got %d", wikipedia.MaxSummarySentences, cfg.SummaryMaxSentences)
+	}
+
+	if cfg.SummaryMaxChars < 1 || cfg.SummaryMaxChars > wikipedia.MaxSummaryChars {
+		problem("summary_max_chars must be between 1 and %d, got %d", wikipedia.MaxSummaryChars, cfg.SummaryMaxChars)
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problem("trusted_proxies must contain IP addresses or CIDR ranges, got %q", proxy)
+			}
+		}
+	}
+
+	if err := cfg.CORS.Validate(); err != nil {
+		problem("cors: %s", err)
+	}
+
+	prefixes := make([]string, 0, len(cfg.CORSOverrides))
+	for prefix := range cfg.CORSOverrides {
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		if err := cfg.CORSOverrides[prefix].Apply(cfg.CORS).Validate(); err != nil {
+			problem("cors_overrides of %s: %s", prefix, err)
+		}
+	}
+
+	names := make(map[string]bool, len(cfg.APIKeys))
+	for i, key := range cfg.APIKeys {
+		switch {
+		case key.Name == "":
+			problem("api key %d has no name", i+1)
+		case names[key.Name]:
+			problem("there is more than one api key named %q", key.Name)
+		}
+
+		if !isSHA256(key.SHA256) {
+			problem("the sha256 of api key %q must be 64 hexadecimal digits", key.Name)
+		}
+
+		if key.DailyQuota < 0 {
+			problem("the daily_quota of api key %q must not be negative", key.Name)
+		}
+
+		names[key.Name] = true
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// negativeSettings returns a problem for every negative number or duration
+// among the fields of v and its nested structs.
+func negativeSettings(v reflect.Value, prefix string) []string {
+	var problems []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+		switch f := v.Field(i); {
+		case f.Type() == durationType && f.Int() < 0:
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %s", name, f.Interface()))
+		case f.Kind() == reflect.Int && f.Int() < 0:
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %d", name, f.Int()))
+		case f.Kind() == reflect.Struct && f.Type() != reflect.TypeOf(RateLimit{}):
+			problems = append(problems, negativeSettings(f, name+".")...)
+		}
+	}
+
+	return problems
+}
+
+func isSHA256(value string) bool {
+	decoded, err := hex.DecodeString(value)
+
+	return err == nil && len(decoded) == sha256.Size
+}
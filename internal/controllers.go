@@ -1,48 +1,169 @@
 package internal
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"os"
-	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+const (
+	// maxBatchTitles is the maximum number of titles accepted by
+	// SearchBatch.
+	maxBatchTitles = 500
+
+	// defaultSuggestions is the number of suggestions returned by Suggest
+	// when no limit is requested.
+	defaultSuggestions = 10
 )
 
 // health godoc
-// @Summary		Check if the API is operational.
-// @Description	Check if the API is operational.
-// @Accept			json
-// @Produce		json
-// @Success		200	{object}	CheckHealthResponse
-// @Failure		500	{object}	InternalServerErrorResponse
-// @Router			/api/v1 [get]
+//
+//	@Summary		Check if the API is operational.
+//	@Description	Check if the API is operational. The status is degraded while the circuit breaker around the wikipedia API is open, in which case lookups fail fast with a 503. While the server is shutting down, the status is shutting_down and a 503 is returned so that load balancers stop routing requests to it.
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	CheckHealthResponse
+//	@Failure		429	{object}	ErrorResponse
+//	@Failure		503	{object}	CheckHealthResponse
+//	@Failure		500	{object}	InternalServerErrorResponse
+//	@Router			/api/v1 [get]
 func Health(c *gin.Context) {
-	c.JSON(http.StatusOK, CheckHealthResponse{
+	response := CheckHealthResponse{
 		Status: "operational",
-	})
+	}
+
+	if upstreamBreaker != nil {
+		state := upstreamBreaker.State()
+		response.CircuitBreaker = state.String()
+		if state == wikipedia.BreakerOpen {
+			response.Status = "degraded"
+		}
+	}
+
+	if !ready.Load() {
+		response.Status = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // search godoc
 //
 //	@Summary		Search for a short description of a person, place, or thing.
-//	@Description	Search for a short description of a person, place, or thing.
+//	@Description	Search for a short description of a person, place, or thing. Redirects are followed and the canonical title of the article is returned along with the redirects that were followed. The description is taken from the short description template of the article, falling back to the page description and then to the Wikidata description; the source field tells which one was used.
 //	@Accept			json
 //	@Produce		json
-//	@Param			query	query		string	true	"The name of the person, place, or thing you want to search for."
-//	@Success		200		{object}	SuccessResponse
-//	@Failure		400		{object}	ErrorResponse
-//	@Failure		500		{object}	InternalServerErrorResponse
+//	@Security		ApiKeyAuth
+//	@Param			query			query		string	true	"The name of the person, place, or thing you want to search for."
+//	@Param			lang			query		string	false	"The language edition of Wikipedia to search, e.g. de. Defaults to en."
+//	@Param			did_you_mean	query		bool	false	"If the article is missing, run a full-text search and return the best matching titles and a spelling suggestion."
+//	@Success		200				{object}	SuccessResponse
+//	@Header			200				{string}	X-Cache	"HIT if the response was served from the cache, MISS otherwise."
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		429				{object}	ErrorResponse
+//	@Failure		500				{object}	InternalServerErrorResponse
+//	@Failure		502				{object}	ErrorResponse
+//	@Failure		503				{object}	ServiceUnavailableErrorResponse
+//	@Header			503				{string}	Retry-After	"The number of seconds after which the wikipedia API may be called again."
+//	@Failure		504				{object}	GatewayTimeoutErrorResponse
 //	@Router			/api/v1/search [get]
 func Search(c *gin.Context) {
-	wikipediaURL := os.Getenv("WIKIPEDIA_API_URL")
+	query := c.Query("query")
+	if query == "" {
+		BadRequestErrorHandler(c, "Query parameter is required.")
+
+		return
+	}
+
+	query, ok := validateTitle(c, "query", query)
+	if !ok {
+		return
+	}
+
+	lang, ok := requestLanguage(c)
+	if !ok {
+		return
+	}
+
+	suggest, ok := queryBool(c, "did_you_mean")
+	if !ok {
+		return
+	}
+
+	result, err := lookupShortDescription(c, lang, query)
+	if err != nil {
+		UpstreamErrorHandler(c, err)
+
+		return
+	}
 
-	if wikipediaURL == "" {
-		wikipediaURL = "https://en.wikipedia.org/w/api.php"
+	observeLookup(result.err)
+
+	switch {
+	case errors.Is(result.err, wikipedia.ErrMissing) && suggest:
+		didYouMean(c, lang, query)
+	case errors.Is(result.err, wikipedia.ErrMissing):
+		HttpMissingHandler(c)
+	case errors.Is(result.err, wikipedia.ErrNoDescription):
+		HttpNoDescriptionHandler(c)
+	default:
+		HttpSuccessHandler(c, result.result)
 	}
+}
+
+// didYouMean responds to a search for a missing article with the titles of
+// the best matching articles and a spelling suggestion. If the full-text
+// search fails, the plain missing response is sent instead.
+func didYouMean(c *gin.Context, lang, query string) {
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
+
+	// Unlike titles, full-text searches do not treat underscores as spaces.
+	results, err := wikipediaClient.ForLanguage(lang).Search(ctx, strings.ReplaceAll(query, "_", " "), settings.DidYouMeanResults)
+	if err != nil {
+		log.Printf("Request ID: %s, Error: full-text search failed: %s", c.GetString("reqID"), err.Error())
+		HttpMissingHandler(c)
 
+		return
+	}
+
+	HttpMissingWithSuggestionsHandler(c, results)
+}
+
+// summary godoc
+//
+//	@Summary		Get the summary of a person, place, or thing.
+//	@Description	Get the plain-text lead section of a Wikipedia article, limited to a number of sentences or characters. Without either parameter the summary is limited to the largest number of sentences the server allows. Redirects are followed.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			query		query		string	true	"The name of the person, place, or thing you want the summary of."
+//	@Param			lang		query		string	false	"The language edition of Wikipedia to search, e.g. de. Defaults to en."
+//	@Param			sentences	query		int		false	"The maximum number of sentences of the summary."
+//	@Param			chars		query		int		false	"The approximate maximum number of characters of the summary."
+//	@Success		200			{object}	SummaryResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		429			{object}	ErrorResponse
+//	@Failure		500			{object}	InternalServerErrorResponse
+//	@Failure		502			{object}	ErrorResponse
+//	@Failure		503			{object}	ServiceUnavailableErrorResponse
+//	@Header			503			{string}	Retry-After	"The number of seconds after which the wikipedia API may be called again."
+//	@Failure		504			{object}	GatewayTimeoutErrorResponse
+//	@Router			/api/v1/summary [get]
+func Summary(c *gin.Context) {
 	query := c.Query("query")
 	if query == "" {
 		BadRequestErrorHandler(c, "Query parameter is required.")
@@ -50,60 +171,224 @@ func Search(c *gin.Context) {
 		return
 	}
 
-	url := fmt.Sprintf("%s?action=query&prop=revisions&titles=%s&rvlimit=1&formatversion=2&format=json&rvprop=content", wikipediaURL, query)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		InternalServerErrorHandler(c, err)
+	query, ok := validateTitle(c, "query", query)
+	if !ok {
+		return
+	}
 
+	lang, ok := requestLanguage(c)
+	if !ok {
 		return
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		InternalServerErrorHandler(c, err)
+	sentences, ok := queryLimit(c, "sentences", settings.SummaryMaxSentences)
+	if !ok {
+		return
+	}
 
+	chars, ok := queryLimit(c, "chars", settings.SummaryMaxChars)
+	if !ok {
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		WikipediaApiErrorHandler(c, resp.StatusCode)
+	if sentences > 0 && chars > 0 {
+		BadRequestErrorHandler(c, "Only one of the sentences and chars parameters can be set.")
 
 		return
 	}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		InternalServerErrorHandler(c, err)
+	// The configured maximum also bounds requests that ask for no limit,
+	// which would otherwise get the whole lead section.
+	if sentences == 0 && chars == 0 {
+		sentences = settings.SummaryMaxSentences
+	}
+
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
 
+	summary, err := wikipediaClient.ForLanguage(lang).Summary(ctx, query, wikipedia.SummaryOptions{
+		Sentences: sentences,
+		Chars:     chars,
+	})
+	switch {
+	case errors.Is(err, wikipedia.ErrMissing):
+		HttpMissingHandler(c)
+	case errors.Is(err, wikipedia.ErrNoSummary):
+		HttpNoSummaryHandler(c)
+	case err != nil:
+		UpstreamErrorHandler(c, err)
+	default:
+		HttpSummaryHandler(c, summary)
+	}
+}
+
+// suggest godoc
+//
+//	@Summary		Suggest people, places, or things whose names start with a prefix.
+//	@Description	Suggest Wikipedia articles whose titles start with a prefix, ranked by relevance, together with their short descriptions. Meant for building a typeahead.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			prefix	query		string	true	"The beginning of the name of the person, place, or thing you are looking for."
+//	@Param			lang	query		string	false	"The language edition of Wikipedia to search, e.g. de. Defaults to en."
+//	@Param			limit	query		int		false	"The maximum number of suggestions, at most 50. Defaults to 10."
+//	@Success		200		{object}	SuggestResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	InternalServerErrorResponse
+//	@Failure		502		{object}	ErrorResponse
+//	@Failure		503		{object}	ServiceUnavailableErrorResponse
+//	@Header			503		{string}	Retry-After	"The number of seconds after which the wikipedia API may be called again."
+//	@Failure		504		{object}	GatewayTimeoutErrorResponse
+//	@Router			/api/v1/suggest [get]
+func Suggest(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		BadRequestErrorHandler(c, "Prefix parameter is required.")
+
+		return
+	}
+
+	prefix, ok := validateTitle(c, "prefix", prefix)
+	if !ok {
 		return
 	}
 
-	var response WikipediaResponse
+	lang, ok := requestLanguage(c)
+	if !ok {
+		return
+	}
 
-	err = json.Unmarshal(body, &response)
+	limit, ok := queryLimit(c, "limit", wikipedia.MaxTitlesPerQuery)
+	if !ok {
+		return
+	}
+
+	if limit == 0 {
+		limit = defaultSuggestions
+	}
+
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
+
+	suggestions, err := wikipediaClient.ForLanguage(lang).Suggest(ctx, prefix, limit)
 	if err != nil {
-		InternalServerErrorHandler(c, err)
+		UpstreamErrorHandler(c, err)
 
 		return
 	}
 
-	if response.Query.Pages[0].Missing {
-		HttpMissingHandler(c)
+	HttpSuggestHandler(c, suggestions)
+}
 
+// batch search godoc
+//
+//	@Summary		Search for the short descriptions of many people, places, or things at once.
+//	@Description	Search for the short descriptions of up to 500 titles in a single request. The response contains one result per title, in request order. A title that is not a valid page title gets an error result with code 400 and does not fail the other titles.
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			titles	body		[]string	true	"The names of the people, places, or things you want to search for."
+//	@Param			lang	query		string		false	"The language edition of Wikipedia to search, e.g. de. Defaults to en."
+//	@Success		200		{object}	BatchResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		429		{object}	ErrorResponse
+//	@Failure		500		{object}	InternalServerErrorResponse
+//	@Failure		502		{object}	ErrorResponse
+//	@Failure		503		{object}	ServiceUnavailableErrorResponse
+//	@Header			503		{string}	Retry-After	"The number of seconds after which the wikipedia API may be called again."
+//	@Failure		504		{object}	GatewayTimeoutErrorResponse
+//	@Router			/api/v1/search/batch [post]
+func SearchBatch(c *gin.Context) {
+	lang, ok := requestLanguage(c)
+	if !ok {
 		return
 	}
 
-	content := response.Query.Pages[0].Revisions[0].Content
-	re := regexp.MustCompile(`(?mi){{short description\|(.*?)}}`)
-	shortDescription := re.FindStringSubmatch(content)
+	var titles []string
+	if err := c.ShouldBindJSON(&titles); err != nil || len(titles) == 0 {
+		BadRequestErrorHandler(c, "Request body must be a non-empty JSON array of titles.")
 
-	if len(shortDescription) == 0 {
-		HttpNoDescriptionHandler(c)
+		return
+	}
+
+	if len(titles) > maxBatchTitles {
+		BadRequestErrorHandler(c, fmt.Sprintf("A batch request can contain at most %d titles.", maxBatchTitles))
+
+		return
+	}
+
+	ctx, cancel := upstreamContext(c)
+	defer cancel()
+
+	results, err := wikipediaClient.ForLanguage(lang).ShortDescriptions(ctx, titles)
+	if err != nil {
+		UpstreamErrorHandler(c, err)
 
 		return
 	}
 
-	HttpSuccessHandler(c, shortDescription[1])
+	HttpBatchHandler(c, results)
+}
+
+// requestLanguage returns the language requested with the lang query
+// parameter, or the default language if there is none. If the language is not
+// allowed, it responds with a 400 and returns false.
+func requestLanguage(c *gin.Context) (string, bool) {
+	lang := c.Query("lang")
+	if lang == "" {
+		return settings.Languages[0], true
+	}
+
+	for _, allowed := range settings.Languages {
+		if lang == allowed {
+			return lang, true
+		}
+	}
+
+	BadRequestErrorHandler(c, fmt.Sprintf("Unsupported language '%s'. Supported languages are: %s.", lang, strings.Join(settings.Languages, ", ")))
+
+	return "", false
+}
+
+// queryBool returns the value of an optional boolean query parameter, in any
+// of the forms accepted by strconv.ParseBool, or false if it is not set. If
+// the value is invalid, it responds with a 400 and returns false.
+func queryBool(c *gin.Context, name string) (bool, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return false, true
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		BadRequestErrorHandler(c, fmt.Sprintf("The %s parameter must be true or false.", name))
+
+		return false, false
+	}
+
+	return value, true
+}
+
+// queryLimit returns the value of an optional integer query parameter that
+// must be between 1 and max, or 0 if it is not set. If the value is invalid,
+// it responds with a 400 and returns false.
+func queryLimit(c *gin.Context, name string, max int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > max {
+		BadRequestErrorHandler(c, fmt.Sprintf("The %s parameter must be an integer between 1 and %d.", name, max))
+
+		return 0, false
+	}
+
+	return value, true
 }
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+)
+
+// corsHandler returns the middleware enforcing a CORS policy.
+func corsHandler(p config.CORSPolicy) gin.HandlerFunc {
+	return cors.New(cors.Config{
+		AllowOrigins:     p.AllowOrigins,
+		AllowMethods:     p.AllowMethods,
+		AllowHeaders:     p.AllowHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           p.MaxAge,
+		AllowWildcard:    true,
+	})
+}
+
+// corsRoute is the CORS handler for the routes under a path prefix.
+type corsRoute struct {
+	prefix  string
+	handler gin.HandlerFunc
+}
+
+// newCORSRoutes returns the CORS handlers of cfg, the longest prefix first and
+// the default policy last.
+func newCORSRoutes(cfg config.Config) []corsRoute {
+	routes := make([]corsRoute, 0, len(cfg.CORSOverrides)+1)
+	for prefix, override := range cfg.CORSOverrides {
+		routes = append(routes, corsRoute{
+			prefix:  strings.TrimSuffix(prefix, "/"),
+			handler: corsHandler(override.Apply(cfg.CORS)),
+		})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return append(routes, corsRoute{handler: corsHandler(cfg.CORS)})
+}
+
+// CORS applies the CORS policy of the requested path: the override with the
+// longest matching prefix in config.Config.CORSOverrides, or else
+// config.Config.CORS.
+// Preflight requests are answered directly.
+func CORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, route := range corsRoutes {
+			if route.prefix == "" || path == route.prefix || strings.HasPrefix(path, route.prefix+"/") {
+				route.handler(c)
+
+				return
+			}
+		}
+	}
+}
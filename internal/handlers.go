@@ -1,46 +1,174 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
 )
 
-func HttpSuccessHandler(c *gin.Context, shortDescription string) {
-	c.JSON(http.StatusOK, SuccessResponse{
+func HttpSuccessHandler(c *gin.Context, result *wikipedia.Result) {
+	c.JSON(http.StatusOK, newSuccessResponse(result))
+}
+
+func HttpMissingHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, newMissingResponse())
+}
+
+func HttpMissingWithSuggestionsHandler(c *gin.Context, results *wikipedia.SearchResults) {
+	response := newMissingResponse()
+	response.Suggestions = results.Titles
+	response.DidYouMean = results.Suggestion
+
+	c.JSON(http.StatusOK, response)
+}
+
+func HttpNoDescriptionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, newNoDescriptionResponse())
+}
+
+func HttpSummaryHandler(c *gin.Context, summary *wikipedia.Summary) {
+	c.JSON(http.StatusOK, SummaryResponse{
 		Status: "success",
-		Data:   Data{ShortDescription: shortDescription},
+		Data: SummaryData{
+			Summary:   summary.Extract,
+			Language:  summary.Language,
+			Title:     summary.Title,
+			PageID:    summary.PageID,
+			Redirects: newRedirects(summary.Redirects),
+		},
 	})
 }
 
-func HttpMissingHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, MissingResponse{
+func HttpNoSummaryHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, NoSummaryResponse{
+		Status:  "success",
+		Message: "No summary found for this article.",
+		Missing: false,
+	})
+}
+
+func HttpSuggestHandler(c *gin.Context, suggestions []wikipedia.Suggestion) {
+	data := make([]Suggestion, len(suggestions))
+	for i, suggestion := range suggestions {
+		data[i] = Suggestion{
+			Title:            suggestion.Title,
+			PageID:           suggestion.PageID,
+			ShortDescription: suggestion.ShortDescription,
+			Source:           string(suggestion.Source),
+		}
+	}
+
+	c.JSON(http.StatusOK, SuggestResponse{
+		Status: "success",
+		Data:   data,
+	})
+}
+
+func HttpBatchHandler(c *gin.Context, results []wikipedia.BatchResult) {
+	data := make([]BatchResult, len(results))
+	for i, result := range results {
+		data[i].Query = result.Query
+
+		var invalidTitleErr *wikipedia.InvalidTitleError
+		switch {
+		case result.Err == nil:
+			observeLookup(nil)
+			data[i].Result = newSuccessResponse(result.Result)
+		case errors.Is(result.Err, wikipedia.ErrMissing):
+			observeLookup(result.Err)
+			data[i].Result = newMissingResponse()
+		case errors.Is(result.Err, wikipedia.ErrNoDescription):
+			observeLookup(result.Err)
+			data[i].Result = newNoDescriptionResponse()
+		case errors.As(result.Err, &invalidTitleErr):
+			// The title was rejected before it was looked up.
+			data[i].Result = ErrorResponse{
+				Status: "error",
+				Errors: []HTTPError{newInvalidTitleError(c, fmt.Sprintf("titles[%d]", i), invalidTitleErr)},
+			}
+		default:
+			log.Printf("Request ID: %s, Error: %s", c.GetString("reqID"), result.Err.Error())
+			data[i].Result = ErrorResponse{
+				Status: "error",
+				Errors: []HTTPError{{
+					Code:      http.StatusInternalServerError,
+					RequestID: c.GetString("reqID"),
+					Detail:    "An internal server error occurred. Please contact the developer at youssefsobhy22@gmail.com and provide the request ID.",
+				}},
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, BatchResponse{
+		Status: "success",
+		Data:   data,
+	})
+}
+
+func newSuccessResponse(result *wikipedia.Result) SuccessResponse {
+	return SuccessResponse{
+		Status: "success",
+		Data: Data{
+			ShortDescription: result.ShortDescription,
+			Source:           string(result.Source),
+			Language:         result.Language,
+			Title:            result.Title,
+			PageID:           result.PageID,
+			Redirects:        newRedirects(result.Redirects),
+		},
+	}
+}
+
+func newRedirects(redirects []wikipedia.Redirect) []Redirect {
+	converted := make([]Redirect, len(redirects))
+	for i, redirect := range redirects {
+		converted[i] = Redirect{
+			From:     redirect.From,
+			To:       redirect.To,
+			Fragment: redirect.Fragment,
+		}
+	}
+
+	return converted
+}
+
+func newMissingResponse() MissingResponse {
+	return MissingResponse{
 		Status:  "success",
 		Message: "No wikipedia article found.",
 		Missing: true,
-	})
+	}
 }
 
-func HttpNoDescriptionHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, NoDescriptionResponse{
+func newNoDescriptionResponse() NoDescriptionResponse {
+	return NoDescriptionResponse{
 		Status:  "success",
 		Message: "No short description found for this article.",
 		Missing: false,
-	})
+	}
 }
 
 func HttpErrorHandler(c *gin.Context, code int, message string) {
-	c.JSON(code, ErrorResponse{
+	httpErrorHandler(c, HTTPError{
+		Code:   code,
+		Detail: message,
+	})
+}
+
+func httpErrorHandler(c *gin.Context, httpError HTTPError) {
+	httpError.RequestID = c.GetString("reqID")
+
+	c.JSON(httpError.Code, ErrorResponse{
 		Status: "error",
-		Errors: []HTTPError{
-			{
-				Code:      code,
-				RequestID: c.GetString("reqID"),
-				Detail:    message,
-			},
-		},
+		Errors: []HTTPError{httpError},
 	})
 }
 
@@ -48,6 +176,19 @@ func BadRequestErrorHandler(c *gin.Context, message string) {
 	HttpErrorHandler(c, http.StatusBadRequest, message)
 }
 
+func InvalidTitleErrorHandler(c *gin.Context, param string, err *wikipedia.InvalidTitleError) {
+	httpErrorHandler(c, newInvalidTitleError(c, param, err))
+}
+
+func newInvalidTitleError(c *gin.Context, param string, err *wikipedia.InvalidTitleError) HTTPError {
+	return HTTPError{
+		Code:      http.StatusBadRequest,
+		RequestID: c.GetString("reqID"),
+		Detail:    fmt.Sprintf("Invalid %s parameter. %s", param, err.Detail),
+		Rule:      err.Rule,
+	}
+}
+
 func WikipediaApiErrorHandler(c *gin.Context, httpStatusCode int) {
 	HttpErrorHandler(
 		c,
@@ -56,6 +197,129 @@ func WikipediaApiErrorHandler(c *gin.Context, httpStatusCode int) {
 	)
 }
 
+// UpstreamErrorHandler reports an error returned by the wikipedia client.
+func UpstreamErrorHandler(c *gin.Context, err error) {
+	if errors.Is(err, context.Canceled) && c.Request.Context().Err() != nil {
+		// The client has gone away, there is no one left to respond to.
+		log.Printf("Request ID: %s, Error: client closed the request", c.GetString("reqID"))
+		c.AbortWithStatus(499)
+
+		return
+	}
+
+	var breakerErr *wikipedia.CircuitBreakerError
+	if errors.As(err, &breakerErr) {
+		ServiceUnavailableErrorHandler(c, breakerErr.RetryAfter)
+
+		return
+	}
+
+	var maxLagErr *wikipedia.MaxLagError
+	if errors.As(err, &maxLagErr) {
+		ServiceUnavailableErrorHandler(c, maxLagErr.RetryAfter)
+
+		return
+	}
+
+	if isTimeout(err) {
+		GatewayTimeoutErrorHandler(c, err)
+
+		return
+	}
+
+	var statusErr *wikipedia.StatusError
+	if errors.As(err, &statusErr) {
+		WikipediaApiErrorHandler(c, statusErr.StatusCode)
+
+		return
+	}
+
+	var apiErr *wikipedia.APIError
+	if errors.As(err, &apiErr) {
+		MediaWikiErrorHandler(c, apiErr)
+
+		return
+	}
+
+	if errors.Is(err, wikipedia.ErrUnexpectedResponse) {
+		BadGatewayErrorHandler(c, err)
+
+		return
+	}
+
+	var invalidTitleErr *wikipedia.InvalidTitleError
+	if errors.As(err, &invalidTitleErr) {
+		InvalidTitleErrorHandler(c, "query", invalidTitleErr)
+
+		return
+	}
+
+	InternalServerErrorHandler(c, err)
+}
+
+func GatewayTimeoutErrorHandler(c *gin.Context, err error) {
+	HttpErrorHandler(
+		c,
+		http.StatusGatewayTimeout,
+		"The wikipedia API did not respond in time. Please try again later.",
+	)
+
+	log.Printf("Request ID: %s, Error: %s", c.GetString("reqID"), err.Error())
+}
+
+// MediaWikiErrorHandler reports an error the MediaWiki API returned in the body
+// of its response, with an HTTP status code that depends on the error code.
+func MediaWikiErrorHandler(c *gin.Context, err *wikipedia.APIError) {
+	httpErrorHandler(c, HTTPError{
+		Code:         mediaWikiErrorStatus(err.Code),
+		Detail:       fmt.Sprintf("The wikipedia API reported an error: %s", err.Info),
+		UpstreamCode: err.Code,
+	})
+
+	log.Printf("Request ID: %s, Error: %s", c.GetString("reqID"), err.Error())
+}
+
+// mediaWikiErrorStatus returns the HTTP status code reported for a MediaWiki
+// API error code: 400 for errors caused by the title of the request, 503 for
+// errors that go away once the wikipedia API recovers and 502 for anything
+// else.
+func mediaWikiErrorStatus(code string) int {
+	switch code {
+	case "invalidtitle", "toomanyvalues":
+		return http.StatusBadRequest
+	case "maxlag", "ratelimited", "readonly":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func BadGatewayErrorHandler(c *gin.Context, err error) {
+	HttpErrorHandler(
+		c,
+		http.StatusBadGateway,
+		"The wikipedia API returned an unexpected response. Please try again later.",
+	)
+
+	log.Printf("Request ID: %s, Error: %s", c.GetString("reqID"), err.Error())
+}
+
+// ServiceUnavailableErrorHandler responds with a 503 telling the client to
+// come back after retryAfter, rounded up to whole seconds.
+func ServiceUnavailableErrorHandler(c *gin.Context, retryAfter time.Duration) {
+	seconds := ceilSeconds(retryAfter)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	HttpErrorHandler(
+		c,
+		http.StatusServiceUnavailable,
+		"The wikipedia API is currently unavailable. Please try again later.",
+	)
+}
+
 func InternalServerErrorHandler(c *gin.Context, err error) {
 	HttpErrorHandler(
 		c,
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger logs every request in the format of gin.Logger, with the name of
+// the API key the request was authenticated with, or "-" if none, after the
+// client IP. The value of the api_key query parameter is redacted so that
+// secrets are not written to the logs.
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+
+		path, query, ok := strings.Cut(param.Path, "?")
+		if ok {
+			path += "?" + redactQuery(query)
+		}
+
+		apiKey, _ := param.Keys[APIKeyContextKey].(string)
+		if apiKey == "" {
+			apiKey = "-"
+		}
+
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s | %s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			apiKey,
+			methodColor, param.Method, resetColor,
+			path,
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactQuery returns the raw query with the value of every api_key parameter
+// replaced by REDACTED, keeping the other parameters as they were sent.
+func redactQuery(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && name == "api_key" {
+			params[i] = key + "=REDACTED"
+		}
+	}
+
+	return strings.Join(params, "&")
+}
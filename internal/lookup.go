@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// lookupGroup coalesces concurrent upstream lookups of the same title.
+var lookupGroup singleflight.Group
+
+// lookupShortDescription looks up the short description of title in the given
+// language edition of Wikipedia, serving it
+// from the cache when possible and reporting whether it did in the X-Cache
+// response header. Missing articles and articles without a short description
+// are cached for the shorter negative TTL; any other error is returned and
+// not cached.
+//
+// Concurrent lookups of the same title share a single upstream call. That
+// call is not bound to the request that started it, so a client going away
+// does not fail the others waiting for the same title; it only stops waiting
+// itself.
+func lookupShortDescription(c *gin.Context, lang, title string) (lookup, error) {
+	key := cacheKey(lang, title)
+
+	_, span := tracer.Start(c.Request.Context(), "cache.Get", trace.WithAttributes(attribute.String("cache.key", key)))
+	cached, ok := lookupCache.Get(key)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	span.End()
+
+	if ok {
+		c.Header("X-Cache", "HIT")
+		observeCache(true)
+
+		return cached, nil
+	}
+
+	c.Header("X-Cache", "MISS")
+	observeCache(false)
+
+	// The span of the shared call belongs to the trace of the request that
+	// started it.
+	parent := c.Request.Context()
+	flight := lookupGroup.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := detachedUpstreamContext(parent)
+		defer cancel()
+
+		ctx, span := tracer.Start(ctx, "wikipedia.ShortDescription", trace.WithAttributes(
+			attribute.String("wikipedia.language", lang),
+			attribute.String("wikipedia.title", title),
+		))
+		defer span.End()
+
+		result, err := wikipediaClient.ForLanguage(lang).ShortDescription(ctx, title)
+		if err != nil {
+			span.SetAttributes(attribute.String("wikipedia.error", err.Error()))
+		}
+		switch {
+		case err == nil:
+			lookupCache.Set(key, lookup{result: result}, settings.CacheTTL)
+		case errors.Is(err, wikipedia.ErrMissing), errors.Is(err, wikipedia.ErrNoDescription):
+			lookupCache.Set(key, lookup{err: err}, settings.CacheNegativeTTL)
+		default:
+			return nil, err
+		}
+
+		return lookup{result: result, err: err}, nil
+	})
+
+	select {
+	case shared := <-flight:
+		if shared.Err != nil {
+			return lookup{}, shared.Err
+		}
+
+		return shared.Val.(lookup), nil
+	case <-c.Request.Context().Done():
+		return lookup{}, c.Request.Context().Err()
+	}
+}
+
+// cacheKey returns the key title is cached under in the given language. Titles
+// MediaWiki treats as the same page share a key: underscores are spaces and
+// the first letter is case-insensitive.
+func cacheKey(lang, title string) string {
+	title = strings.ReplaceAll(title, "_", " ")
+	if first, size := utf8.DecodeRuneInString(title); first != utf8.RuneError {
+		title = string(unicode.ToUpper(first)) + title[size:]
+	}
+
+	return lang + ":" + title
+}
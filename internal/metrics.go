@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// metricsNamespace prefixes the names of all metrics.
+const metricsNamespace = "wikipedia_api"
+
+var (
+	// metricsRegistry holds the metrics served by Metrics. It is not the
+	// default registry so that only the metrics below are exposed.
+	metricsRegistry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	upstreamRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by requests to the MediaWiki API, by host.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host"})
+
+	upstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upstream_errors_total",
+		Help:      "Failed requests to the MediaWiki API, by MediaWiki error code, http_<status> for other error statuses or network.",
+	}, []string{"code"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_requests_total",
+		Help:      "Lookups of the cache, by result: hit or miss.",
+	}, []string{"result"})
+
+	lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "lookups_total",
+		Help:      "Short description lookups, by outcome: success, missing or no_description.",
+	}, []string{"outcome"})
+
+	// cacheHits and cacheMisses mirror cacheRequests to compute the hit
+	// ratio.
+	cacheHits, cacheMisses atomic.Uint64
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		upstreamRequestDuration,
+		upstreamErrors,
+		cacheRequests,
+		lookups,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_hit_ratio",
+			Help:      "Share of cache lookups that were hits since the process started.",
+		}, func() float64 {
+			hits, misses := cacheHits.Load(), cacheMisses.Load()
+			if hits+misses == 0 {
+				return 0
+			}
+
+			return float64(hits) / float64(hits+misses)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_entries",
+			Help:      "Entries currently held by the cache.",
+		}, func() float64 {
+			return float64(lookupCache.Len())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the circuit breaker around the MediaWiki API: 0 closed or disabled, 1 half-open, 2 open.",
+		}, func() float64 {
+			if upstreamBreaker == nil {
+				return 0
+			}
+
+			switch upstreamBreaker.State() {
+			case wikipedia.BreakerHalfOpen:
+				return 1
+			case wikipedia.BreakerOpen:
+				return 2
+			default:
+				return 0
+			}
+		}),
+	)
+}
+
+// metrics godoc
+//
+//	@Summary		Get the metrics of the server.
+//	@Description	Get the metrics of the server in the Prometheus text format: HTTP requests by route and status, calls to the wikipedia API and their errors, the cache hit ratio, lookup outcomes and the circuit breaker state.
+//	@Produce		plain
+//	@Success		200	{string}	string
+//	@Router			/metrics [get]
+func Metrics() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+}
+
+// Instrument counts and times every request by route, method and status.
+// Requests that match no route are recorded under the unmatched route.
+func Instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// observeCache records whether a lookup was served from the cache.
+func observeCache(hit bool) {
+	if hit {
+		cacheHits.Add(1)
+		cacheRequests.WithLabelValues("hit").Inc()
+	} else {
+		cacheMisses.Add(1)
+		cacheRequests.WithLabelValues("miss").Inc()
+	}
+}
+
+// observeLookup records the outcome of a short description lookup that did
+// not fail, err being nil, wikipedia.ErrMissing or wikipedia.ErrNoDescription.
+func observeLookup(err error) {
+	switch {
+	case errors.Is(err, wikipedia.ErrMissing):
+		lookups.WithLabelValues("missing").Inc()
+	case errors.Is(err, wikipedia.ErrNoDescription):
+		lookups.WithLabelValues("no_description").Inc()
+	default:
+		lookups.WithLabelValues("success").Inc()
+	}
+}
+
+// instrumentedTransport times the requests to the MediaWiki API, counts those
+// that fail and records a span for each of them.
+type instrumentedTransport struct {
+	// next sends the requests, http.DefaultTransport if nil.
+	next http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	req, span := startUpstreamSpan(req)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	upstreamRequestDuration.WithLabelValues(req.URL.Host).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		upstreamErrors.WithLabelValues("network").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+
+	switch code := resp.Header.Get("MediaWiki-API-Error"); {
+	case code != "":
+		// MediaWiki names the error of the response in this header, even
+		// when it answers with a 200.
+		upstreamErrors.WithLabelValues(code).Inc()
+		span.SetAttributes(attribute.String("mediawiki.error", code))
+		span.SetStatus(codes.Error, code)
+	case resp.StatusCode != http.StatusOK:
+		upstreamErrors.WithLabelValues("http_" + strconv.Itoa(resp.StatusCode)).Inc()
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
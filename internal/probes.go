@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// upstreamProbe checks that the wikipedia API can be reached and remembers
+// the result for the readiness probe interval, so that frequent readiness
+// checks do not add to the load on MediaWiki.
+type upstreamProbe struct {
+	mu      sync.Mutex
+	checked time.Time
+	result  HealthCheck
+	now     func() time.Time
+}
+
+func newUpstreamProbe() *upstreamProbe {
+	return &upstreamProbe{now: time.Now}
+}
+
+// Check returns the result of the last probe if it is recent enough and
+// probes the wikipedia API otherwise. Concurrent callers wait for a single
+// probe.
+func (p *upstreamProbe) Check() HealthCheck {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checked.IsZero() && p.now().Sub(p.checked) < settings.ReadinessProbeInterval {
+		result := p.result
+		result.Cached = true
+
+		return result
+	}
+
+	// The probe outlives the readiness request that started it, so that
+	// its result can be reused even if that request is cancelled.
+	ctx, cancel := detachedUpstreamContext(context.Background())
+	defer cancel()
+
+	// The probe does not queue behind lookups for an upstream slot, which
+	// could make it time out on an instance that is merely busy.
+	start := p.now()
+	info, err := wikipediaClient.WithoutConcurrencyLimit().SiteInfo(ctx)
+	p.checked = p.now()
+
+	p.result = HealthCheck{Status: "pass", LatencyMS: latencyMS(p.checked.Sub(start))}
+	if err != nil {
+		p.result.Status = "warn"
+		p.result.Message = err.Error()
+	} else {
+		p.result.Message = info.Generator
+	}
+
+	return p.result
+}
+
+// latencyMS converts d to milliseconds, rounded to a tenth of a millisecond.
+func latencyMS(d time.Duration) float64 {
+	return float64(d.Round(100*time.Microsecond)) / float64(time.Millisecond)
+}
+
+// timed runs check and sets the latency of its result.
+func timed(check func() HealthCheck) HealthCheck {
+	start := time.Now()
+	result := check()
+	result.LatencyMS = latencyMS(time.Since(start))
+
+	return result
+}
+
+// livez godoc
+//
+//	@Summary		Check if the process is alive.
+//	@Description	Check if the process is alive and able to answer requests at all. It does not depend on the wikipedia API, so a failing liveness check means the process should be restarted.
+//	@Produce		json
+//	@Success		200	{object}	LivenessResponse
+//	@Router			/livez [get]
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: "alive"})
+}
+
+// readyz godoc
+//
+//	@Summary		Check if the server is able to serve requests.
+//	@Description	Check if the server is able to serve requests, with the status and latency of every check. The upstream check calls the siteinfo module of the wikipedia API and its result is reused for a while, in which case cached is set. The server is not ready only while it is shutting down or while the configuration is invalid. An open circuit breaker or an unreachable wikipedia API is reported as a warning, since every instance shares the wikipedia API and answers requests with a 503 and a Retry-After while it is down.
+//	@Produce		json
+//	@Success		200	{object}	ReadinessResponse
+//	@Failure		503	{object}	ReadinessResponse
+//	@Router			/readyz [get]
+func Readyz(c *gin.Context) {
+	// Only the checks of this instance fail readiness. Problems of the
+	// wikipedia API would take every instance out of rotation at once.
+	checks := map[string]HealthCheck{
+		"server": timed(func() HealthCheck {
+			if !ready.Load() {
+				return HealthCheck{Status: "fail", Message: "The server is shutting down."}
+			}
+
+			return HealthCheck{Status: "pass"}
+		}),
+		"config": timed(func() HealthCheck {
+			if err := settings.Validate(); err != nil {
+				return HealthCheck{Status: "fail", Message: err.Error()}
+			}
+
+			return HealthCheck{Status: "pass"}
+		}),
+		"cache": timed(func() HealthCheck {
+			if settings.CacheSize == 0 {
+				return HealthCheck{Status: "pass", Message: "disabled"}
+			}
+
+			return HealthCheck{Status: "pass", Message: fmt.Sprintf("%d of %d entries", lookupCache.Len(), settings.CacheSize)}
+		}),
+		"circuit_breaker": timed(func() HealthCheck {
+			if upstreamBreaker == nil {
+				return HealthCheck{Status: "pass", Message: "disabled"}
+			}
+
+			switch state := upstreamBreaker.State(); state {
+			case wikipedia.BreakerOpen, wikipedia.BreakerHalfOpen:
+				return HealthCheck{Status: "warn", Message: state.String()}
+			default:
+				return HealthCheck{Status: "pass", Message: state.String()}
+			}
+		}),
+		"upstream": upstreamCheck.Check(),
+	}
+
+	response := ReadinessResponse{Status: "ready", Checks: checks}
+	for _, check := range checks {
+		if check.Status == "fail" {
+			response.Status = "not_ready"
+		}
+	}
+
+	if response.Status != "ready" {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
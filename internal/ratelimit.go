@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+)
+
+// rateLimitStore keeps the token buckets of the rate limiter. It is an
+// interface so that the buckets can be shared between instances of the
+// service by a backend other than memoryRateLimitStore.
+type rateLimitStore interface {
+	// Take takes a token from the bucket identified by key, which holds up
+	// to limit.Requests tokens and is refilled at limit.Requests tokens per
+	// limit.Period.
+	Take(key string, limit config.RateLimit, now time.Time) rateLimitDecision
+}
+
+// rateLimitDecision is the outcome of taking a token from a bucket.
+type rateLimitDecision struct {
+	// Allowed reports whether a token was available.
+	Allowed bool
+
+	// Remaining is the number of whole tokens left in the bucket.
+	Remaining int
+
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+
+	// RetryAfter is how long until a token is available, zero if Allowed.
+	RetryAfter time.Duration
+}
+
+// memoryRateLimitStore keeps token buckets in memory. Buckets that have been
+// full for a while are dropped, since a new bucket starts full as well. It is
+// safe for concurrent use.
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// rateLimitSweepInterval is how often memoryRateLimitStore drops full
+// buckets.
+const rateLimitSweepInterval = time.Minute
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (s *memoryRateLimitStore) Take(key string, limit config.RateLimit, now time.Time) rateLimitDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= rateLimitSweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+
+		s.lastSweep = now
+	}
+
+	burst := float64(limit.Requests)
+	perToken := limit.Period / time.Duration(limit.Requests)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	decision := rateLimitDecision{Allowed: b.tokens >= 1}
+	if decision.Allowed {
+		b.tokens--
+	} else {
+		decision.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = time.Duration((burst - b.tokens) * float64(perToken))
+	b.full = now.Add(decision.Reset)
+
+	return decision
+}
+
+// TrustProxies sets which proxies r believes about the IP address of the
+// client, from config.Config.TrustedProxies and
+// config.Config.TrustedPlatformHeader. Gin trusts every proxy by default,
+// which would let clients pick a new address, and so a new rate limit, with
+// every request by sending an X-Forwarded-For header.
+func TrustProxies(r *gin.Engine) error {
+	r.TrustedPlatform = settings.TrustedPlatformHeader
+
+	return r.SetTrustedProxies(settings.TrustedProxies)
+}
+
+// RateLimiter limits the number of requests a client can make to a route. A
+// client is identified by the name of its API key if the request was
+// authenticated, or else by its IP address. The limit of a route is taken from
+// config.Config.RouteRateLimits, falling back to config.Config.RateLimit. Every response
+// carries the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers, and requests over the limit get a 429.
+func RateLimiter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+
+		limit, ok := settings.RouteRateLimits[route]
+		if !ok {
+			limit = settings.RateLimit
+		}
+
+		if limit.Disabled() {
+			c.Next()
+
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if name := c.GetString(APIKeyContextKey); name != "" {
+			client = "key:" + name
+		}
+
+		decision := rateLimits.Take(route+" "+client, limit, time.Now())
+
+		c.Header("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(decision.Reset), 10))
+
+		if !decision.Allowed {
+			retryAfter := ceilSeconds(decision.RetryAfter)
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			HttpErrorHandler(c, http.StatusTooManyRequests, fmt.Sprintf("Too many requests. Please try again in %d seconds.", retryAfter))
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ready reports whether the instance accepts new requests. It is cleared as
+// soon as the server is asked to stop, before in-flight requests are drained.
+var ready atomic.Bool
+
+// NewServer returns the HTTP server serving handler with the timeouts and
+// header size limit of the configuration passed to Setup.
+func NewServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: settings.ReadHeaderTimeout,
+		ReadTimeout:       settings.ReadTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+		MaxHeaderBytes:    settings.MaxHeaderBytes,
+	}
+}
+
+// Serve serves requests on ln until ctx is done. It then reports the instance
+// as not ready, keeps serving for the shutdown delay so that load balancers
+// stop routing requests to it, and gives in-flight requests up to the shutdown
+// timeout to complete before closing the remaining connections.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	shutdownDelay, shutdownTimeout := settings.ShutdownDelay, settings.ShutdownTimeout
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	ready.Store(false)
+	log.Printf("Shutting down, draining requests for %s", shutdownDelay+shutdownTimeout)
+
+	// Clients reusing a connection would keep reaching this instance, so
+	// they are asked to open a new one.
+	srv.SetKeepAlivesEnabled(false)
+	time.Sleep(shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+var (
+	settings        = config.Default()
+	wikipediaClient = wikipedia.New()
+	lookupCache     = newCache(settings.CacheSize)
+	upstreamBreaker *wikipedia.CircuitBreaker
+	rateLimits      rateLimitStore = newMemoryRateLimitStore()
+	apiKeys         map[string]*config.APIKey
+	apiKeyQuotas    = newQuotas()
+	corsRoutes      = newCORSRoutes(settings)
+	upstreamCheck   = newUpstreamProbe()
+)
+
+// Setup configures the handlers of this package. It must be called before the
+// router starts serving requests.
+func Setup(cfg config.Config) {
+	settings = cfg
+
+	upstreamBreaker = nil
+	if cfg.BreakerFailureThreshold > 0 {
+		upstreamBreaker = wikipedia.NewCircuitBreaker(cfg.BreakerFailureThreshold, cfg.BreakerCooldown)
+	}
+
+	wikipediaClient = wikipedia.New(
+		wikipedia.WithBaseURL(cfg.WikipediaAPIURL),
+		wikipedia.WithWikidataURL(cfg.WikidataAPIURL),
+		wikipedia.WithHTTPClient(newHTTPClient(cfg)),
+		wikipedia.WithUserAgent(cfg.UserAgent),
+		wikipedia.WithMaxLag(cfg.MaxLag),
+		wikipedia.WithMaxConcurrency(cfg.UpstreamMaxConcurrency),
+		wikipedia.WithRetryPolicy(wikipedia.RetryPolicy{
+			MaxAttempts:   cfg.RetryMaxAttempts,
+			BaseDelay:     cfg.RetryBaseDelay,
+			MaxDelay:      cfg.RetryMaxDelay,
+			MaxRetryAfter: cfg.RetryMaxRetryAfter,
+		}),
+		wikipedia.WithCircuitBreaker(upstreamBreaker),
+		wikipedia.WithWarningHandler(logWarning),
+	)
+	lookupCache = newCache(cfg.CacheSize)
+	rateLimits = newMemoryRateLimitStore()
+
+	apiKeys = make(map[string]*config.APIKey, len(cfg.APIKeys))
+	for i := range cfg.APIKeys {
+		apiKeys[strings.ToLower(cfg.APIKeys[i].SHA256)] = &cfg.APIKeys[i]
+	}
+
+	apiKeyQuotas = newQuotas()
+	corsRoutes = newCORSRoutes(cfg)
+	upstreamCheck = newUpstreamProbe()
+	ready.Store(true)
+}
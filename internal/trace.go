@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the spans of this package with the global tracer provider
+// installed by tracing.Setup.
+var tracer = otel.Tracer("github.com/youssef1337/wikipedia-api/internal")
+
+// requestIDKey is the context key holding the X-Request-Id of the request an
+// upstream call is made for, so that it can be passed on to MediaWiki.
+type requestIDKey struct{}
+
+// Trace starts a server span for every request, continuing the trace of the
+// W3C traceparent header if the client sent one. The span is named after the
+// route and ends once the response is written.
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		// The query may carry an API key, which must not reach the
+		// collector.
+		target := c.Request.URL.EscapedPath()
+		if rawQuery := c.Request.URL.RawQuery; rawQuery != "" {
+			target += "?" + redactQuery(rawQuery)
+		}
+
+		requestID := c.GetString("reqID")
+		ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(c.Request.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(target),
+				attribute.String("request.id", requestID),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// detachedContext returns a context that is never cancelled but carries the
+// trace and the request ID of parent, so that the upstream calls made on it
+// are recorded in the trace of the request that started them.
+func detachedContext(parent context.Context) context.Context {
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(parent))
+
+	return context.WithValue(ctx, requestIDKey{}, parent.Value(requestIDKey{}))
+}
+
+// startUpstreamSpan starts the client span of an upstream request and returns
+// a copy of req carrying the trace context and the request ID in its headers.
+func startUpstreamSpan(req *http.Request) (*http.Request, trace.Span) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.String()),
+			semconv.NetPeerNameKey.String(req.URL.Hostname()),
+		),
+	)
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok && requestID != "" {
+		req.Header.Set("X-Request-Id", requestID)
+	}
+
+	return req, span
+}
@@ -0,0 +1,94 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider, the W3C
+// trace context propagator and the exporter spans are sent to.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+)
+
+// Setup installs the global tracer provider and propagator for the exporter
+// of cfg. Spans of the stdout exporter are written to stdout as they end. The
+// returned function flushes the remaining spans and must be called before the
+// process exits.
+//
+// The W3C trace context of incoming requests is honored whatever the
+// exporter, so that it is passed on to the wikipedia API even when spans are
+// not recorded.
+func Setup(cfg config.Config, stdout io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var option sdktrace.TracerProviderOption
+	switch cfg.TracingExporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		// Spans are written as they end, so that none is lost when the
+		// process exits.
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(stdout))
+		if err != nil {
+			return nil, err
+		}
+
+		option = sdktrace.WithSyncer(exporter)
+	case "otlp":
+		exporter, err := otlptracehttp.New(context.Background(), otlpOptions(cfg.OTLPEndpoint)...)
+		if err != nil {
+			return nil, err
+		}
+
+		option = sdktrace.WithBatcher(exporter)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.TracingExporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		option,
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(cfg.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// otlpOptions returns the options of an exporter sending spans to the
+// /v1/traces path of endpoint, e.g. http://localhost:4318. Headers, TLS
+// certificates, compression and timeouts are left to the standard
+// OTEL_EXPORTER_OTLP_* environment variables read by the exporter.
+func otlpOptions(endpoint string) []otlptracehttp.Option {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		// The endpoint is validated with the configuration.
+		return nil
+	}
+
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(u.Path, "/") + "/v1/traces"),
+	}
+
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	return options
+}
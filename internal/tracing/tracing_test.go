@@ -0,0 +1,90 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+	"github.com/youssef1337/wikipedia-api/internal/tracing"
+)
+
+var _ = Describe("Setup", func() {
+	It("writes spans to stdout as lines of JSON with the stdout exporter", func() {
+		cfg := config.Default()
+		cfg.TracingExporter = "stdout"
+
+		var stdout bytes.Buffer
+		shutdown, err := tracing.Setup(cfg, &stdout)
+		Expect(err).NotTo(HaveOccurred())
+		defer shutdown(context.Background())
+
+		_, span := otel.Tracer("test").Start(context.Background(), "lookup")
+		span.SetAttributes(attribute.Int("wikitext.length", 42))
+		span.End()
+
+		var written struct {
+			Name        string
+			SpanContext struct{ TraceID string }
+			Attributes  []struct{ Key string }
+			Resource    []struct {
+				Key   string
+				Value struct{ Value string }
+			}
+		}
+		Expect(json.Unmarshal(stdout.Bytes(), &written)).To(Succeed())
+		Expect(written.Name).To(Equal("lookup"))
+		Expect(written.SpanContext.TraceID).To(Equal(span.SpanContext().TraceID().String()))
+		Expect(written.Attributes[0].Key).To(Equal("wikitext.length"))
+		Expect(written.Resource).To(ContainElement(HaveField("Value.Value", "wikipedia-api")))
+	})
+
+	It("sends spans to the traces path of the collector with the otlp exporter", func() {
+		var (
+			path, contentType string
+			request           coltracepb.ExportTraceServiceRequest
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+			body, _ := io.ReadAll(r.Body)
+			Expect(proto.Unmarshal(body, &request)).To(Succeed())
+		}))
+		defer server.Close()
+
+		cfg := config.Default()
+		cfg.TracingExporter = "otlp"
+		cfg.OTLPEndpoint = server.URL + "/collector/"
+
+		shutdown, err := tracing.Setup(cfg, io.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, span := otel.Tracer("test").Start(context.Background(), "lookup")
+		span.End()
+		Expect(shutdown(context.Background())).To(Succeed())
+
+		Expect(path).To(Equal("/collector/v1/traces"))
+		Expect(contentType).To(Equal("application/x-protobuf"))
+
+		spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("lookup"))
+		traceID := span.SpanContext().TraceID()
+		Expect(spans[0].TraceId).To(Equal(traceID[:]))
+	})
+})
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
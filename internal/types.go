@@ -6,13 +6,34 @@ type SuccessResponse struct {
 }
 
 type CheckHealthResponse struct {
-	Status string `json:"status" example:"operational"`
+	Status         string `json:"status" example:"operational" enums:"operational,degraded,shutting_down"`
+	CircuitBreaker string `json:"circuit_breaker,omitempty" example:"closed" enums:"closed,open,half-open"`
+}
+
+type LivenessResponse struct {
+	Status string `json:"status" example:"alive"`
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status" example:"ready" enums:"ready,not_ready"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Status    string  `json:"status" example:"pass" enums:"pass,warn,fail"`
+	Message   string  `json:"message,omitempty" example:"MediaWiki 1.41.0-wmf.1"`
+	LatencyMS float64 `json:"latency_ms" example:"84.2"`
+
+	// Cached is set when the result of an earlier check was reused.
+	Cached bool `json:"cached,omitempty" example:"true"`
 }
 
 type MissingResponse struct {
-	Status  string `json:"status" example:"success"`
-	Message string `json:"message" example:"No wikipedia article found."`
-	Missing bool   `json:"missing" example:"true"`
+	Status      string   `json:"status" example:"success"`
+	Message     string   `json:"message" example:"No wikipedia article found."`
+	Missing     bool     `json:"missing" example:"true"`
+	Suggestions []string `json:"suggestions,omitempty" example:"Yoshua Bengio"`
+	DidYouMean  string   `json:"did_you_mean,omitempty" example:"yoshua bengio"`
 }
 
 type NoDescriptionResponse struct {
@@ -21,6 +42,34 @@ type NoDescriptionResponse struct {
 	Missing bool   `json:"missing" example:"false"`
 }
 
+type SummaryResponse struct {
+	Status string      `json:"status" example:"success"`
+	Data   SummaryData `json:"data"`
+}
+
+type NoSummaryResponse struct {
+	Status  string `json:"status" example:"success"`
+	Message string `json:"message" example:"No summary found for this article."`
+	Missing bool   `json:"missing" example:"false"`
+}
+
+type SuggestResponse struct {
+	Status string       `json:"status" example:"success"`
+	Data   []Suggestion `json:"data"`
+}
+
+type BatchResponse struct {
+	Status string        `json:"status" example:"success"`
+	Data   []BatchResult `json:"data"`
+}
+
+// BatchResult holds a SuccessResponse, MissingResponse, NoDescriptionResponse
+// or ErrorResponse for a single title of a batch request.
+type BatchResult struct {
+	Query  string      `json:"query" example:"Yoshua_Bengio"`
+	Result interface{} `json:"result"`
+}
+
 type ErrorResponse struct {
 	Status string      `json:"status" example:"error"`
 	Errors []HTTPError `json:"errors"`
@@ -31,19 +80,59 @@ type InternalServerErrorResponse struct {
 	Errors []InternalServerError `json:"errors"`
 }
 
+type GatewayTimeoutErrorResponse struct {
+	Status string                `json:"status" example:"error"`
+	Errors []GatewayTimeoutError `json:"errors"`
+}
+
+type ServiceUnavailableErrorResponse struct {
+	Status string                    `json:"status" example:"error"`
+	Errors []ServiceUnavailableError `json:"errors"`
+}
+
 type WikipediaApiErrorResponse struct {
 	Status string              `json:"status" example:"error"`
 	Errors []WikipediaApiError `json:"errors"`
 }
 
 type Data struct {
-	ShortDescription string `json:"short_description" example:"A short description of the person, place, or thing you searched for."`
+	ShortDescription string     `json:"short_description" example:"A short description of the person, place, or thing you searched for."`
+	Source           string     `json:"source" example:"wikitext" enums:"wikitext,description,wikidata"`
+	Language         string     `json:"language" example:"en"`
+	Title            string     `json:"title" example:"United States"`
+	PageID           int        `json:"page_id" example:"3434750"`
+	Redirects        []Redirect `json:"redirects"`
+}
+
+type SummaryData struct {
+	Summary   string     `json:"summary" example:"Yoshua Bengio is a Canadian computer scientist, most noted for his work on artificial neural networks and deep learning."`
+	Language  string     `json:"language" example:"en"`
+	Title     string     `json:"title" example:"Yoshua Bengio"`
+	PageID    int        `json:"page_id" example:"47749536"`
+	Redirects []Redirect `json:"redirects"`
+}
+
+type Suggestion struct {
+	Title            string `json:"title" example:"Yoshua Bengio"`
+	PageID           int    `json:"page_id" example:"47749536"`
+	ShortDescription string `json:"short_description" example:"Canadian computer scientist"`
+	Source           string `json:"source" example:"description" enums:"description,wikidata"`
+}
+
+type Redirect struct {
+	From     string `json:"from" example:"USA"`
+	To       string `json:"to" example:"United States"`
+	Fragment string `json:"fragment,omitempty" example:""`
 }
 
 type HTTPError struct {
 	Code      int    `json:"code" example:"400"`
 	RequestID string `json:"request_id" example:"f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"`
 	Detail    string `json:"detail" example:"Query parameter is required"`
+	Rule      string `json:"rule,omitempty" example:"illegal_character"`
+
+	// UpstreamCode is the error code reported by the wikipedia API, if any.
+	UpstreamCode string `json:"upstream_code,omitempty" example:"invalidtitle"`
 }
 
 type WikipediaApiError struct {
@@ -52,28 +141,20 @@ type WikipediaApiError struct {
 	Detail    string `json:"detail" example:"An error occurred while communicating with the wikipedia API with http code 500. Please find more information at https://en.wikipedia.org/w/api.php."`
 }
 
-type InternalServerError struct {
-	Code      int    `json:"code" example:"500"`
+type GatewayTimeoutError struct {
+	Code      int    `json:"code" example:"504"`
 	RequestID string `json:"request_id" example:"f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"`
-	Detail    string `json:"detail" example:"An internal server error occurred. Please contact the developer at youssefsobhy22@gmail.com and provide the request ID."`
-}
-
-type WikipediaResponse struct {
-	Query Query `json:"query"`
-}
-
-type Query struct {
-	Pages []Page `json:"pages"`
+	Detail    string `json:"detail" example:"The wikipedia API did not respond in time. Please try again later."`
 }
 
-type Page struct {
-	PageID    int        `json:"pageid"`
-	Ns        int        `json:"ns"`
-	Title     string     `json:"title"`
-	Revisions []Revision `json:"revisions"`
-	Missing   bool       `json:"missing"`
+type ServiceUnavailableError struct {
+	Code      int    `json:"code" example:"503"`
+	RequestID string `json:"request_id" example:"f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"`
+	Detail    string `json:"detail" example:"The wikipedia API is currently unavailable. Please try again later."`
 }
 
-type Revision struct {
-	Content string `json:"content"`
+type InternalServerError struct {
+	Code      int    `json:"code" example:"500"`
+	RequestID string `json:"request_id" example:"f7a4c0c0-5b5e-4b4c-9c1f-1b5c1b5c1b5c"`
+	Detail    string `json:"detail" example:"An internal server error occurred. Please contact the developer at youssefsobhy22@gmail.com and provide the request ID."`
 }
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/internal/config"
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// newHTTPClient returns the HTTP client used for upstream requests, with the
+// connect and read timeouts of cfg.
+func newHTTPClient(cfg config.Config) *http.Client {
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		// http.DefaultTransport has been replaced, e.g. by httpmock in the
+		// tests, so keep using it.
+		return &http.Client{Transport: &instrumentedTransport{}}
+	}
+
+	transport := defaultTransport.Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   cfg.UpstreamConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = cfg.UpstreamConnectTimeout
+	transport.ResponseHeaderTimeout = cfg.UpstreamReadTimeout
+
+	return &http.Client{Transport: &instrumentedTransport{next: transport}}
+}
+
+// upstreamContext returns the context for the upstream calls made while
+// handling a request. It is cancelled when the client goes away or when the
+// overall upstream timeout is exceeded.
+func upstreamContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	if settings.UpstreamTimeout <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+
+	return context.WithTimeout(c.Request.Context(), settings.UpstreamTimeout)
+}
+
+// detachedUpstreamContext returns the context for an upstream call shared by
+// several requests. It is only cancelled when the overall upstream timeout is
+// exceeded, not when any of the clients goes away, and carries the trace of
+// parent, the request that started the call.
+func detachedUpstreamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx := detachedContext(parent)
+	if settings.UpstreamTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, settings.UpstreamTimeout)
+}
+
+// logWarning logs a warning reported by the MediaWiki API. Warnings usually
+// mean that a parameter sent upstream is deprecated.
+func logWarning(warning wikipedia.Warning) {
+	log.Printf("Warning: wikipedia API module %s: %s", warning.Module, warning.Text)
+}
+
+// isTimeout reports whether err is the result of an upstream deadline being
+// exceeded.
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/youssef1337/wikipedia-api/pkg/wikipedia"
+)
+
+// validateTitle normalizes a title taken from the request parameter named
+// param and checks that it is a valid MediaWiki page title. If it is not, it
+// responds with a 400 naming the broken rule and returns false.
+func validateTitle(c *gin.Context, param, title string) (string, bool) {
+	normalized, err := wikipedia.NormalizeTitle(title)
+	if err != nil {
+		var invalidTitleErr *wikipedia.InvalidTitleError
+		if !errors.As(err, &invalidTitleErr) {
+			InternalServerErrorHandler(c, err)
+
+			return "", false
+		}
+
+		InvalidTitleErrorHandler(c, param, invalidTitleErr)
+
+		return "", false
+	}
+
+	return normalized, true
+}
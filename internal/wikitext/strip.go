@@ -0,0 +1,101 @@
+package wikitext
+
+import "strings"
+
+// escaped replaces the characters that are meaningful to the scanner with
+// HTML entities, so that they are kept as literal text and restored when the
+// text is rendered.
+var escaped = strings.NewReplacer(
+	"{", "&#123;",
+	"}", "&#125;",
+	"[", "&#91;",
+	"]", "&#93;",
+	"|", "&#124;",
+	"=", "&#61;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// stripIgnored removes comments and <ref> footnotes from text and escapes the
+// contents of <nowiki> and <pre> sections, which MediaWiki does not parse as
+// wikitext. Unterminated comments, footnotes and sections run to the end of
+// the text.
+func stripIgnored(text string) string {
+	var b strings.Builder
+	lower := asciiLower(text)
+
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(lower[i:], "<!--"):
+			i = skipPast(lower, i+4, "-->")
+		case isRefTag(lower[i:]):
+			// The footnote is shown at the end of the page, not in the
+			// text.
+			open := skipPast(lower, i, ">")
+			if strings.HasSuffix(lower[i:open], "/>") {
+				i = open
+			} else {
+				i = skipPast(lower, open, "</ref>")
+			}
+		case strings.HasPrefix(lower[i:], "<nowiki/>"), strings.HasPrefix(lower[i:], "<nowiki />"):
+			i = skipPast(lower, i, ">")
+		case strings.HasPrefix(lower[i:], "<nowiki>"), strings.HasPrefix(lower[i:], "<pre>"):
+			tag := lower[i+1 : strings.IndexByte(lower[i:], '>')+i]
+			start := i + len(tag) + 2
+			end := strings.Index(lower[start:], "</"+tag+">")
+			if end < 0 {
+				b.WriteString(escaped.Replace(text[start:]))
+				i = len(text)
+
+				continue
+			}
+
+			b.WriteString(escaped.Replace(text[start : start+end]))
+			i = start + end + len(tag) + 3
+		default:
+			b.WriteByte(text[i])
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// isRefTag reports whether the lower-cased s starts with an opening <ref> tag,
+// as opposed to e.g. <references />.
+func isRefTag(s string) bool {
+	if !strings.HasPrefix(s, "<ref") || len(s) == len("<ref") {
+		return false
+	}
+
+	switch s[len("<ref")] {
+	case '>', '/', ' ', '\t', '\n':
+		return true
+	default:
+		return false
+	}
+}
+
+// skipPast returns the index just after the first occurrence of marker in s
+// at or after from, or len(s) if there is none.
+func skipPast(s string, from int, marker string) int {
+	end := strings.Index(s[from:], marker)
+	if end < 0 {
+		return len(s)
+	}
+
+	return from + end + len(marker)
+}
+
+// asciiLower lower-cases the ASCII letters of s. Unlike strings.ToLower it
+// never changes the length of s, so indexes into the result are valid in s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,313 @@
+// Package wikitext is a small, template-aware scanner for MediaWiki wikitext.
+// It understands just enough of the markup to extract the short description
+// of an article: templates and their parameters, nested templates, links,
+// comments, footnotes and nowiki sections.
+package wikitext
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRe       = regexp.MustCompile(`\[\[([^\[\]|]*)(?:\|([^\[\]]*))?\]\]`)
+	externalRe   = regexp.MustCompile(`\[(?:https?:)?//[^\s\]]+(?:\s+([^\]]*))?\]`)
+	tagRe        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	whitespaceRe = regexp.MustCompile(`[\s\x{00a0}]+`)
+)
+
+// ShortDescription returns the short description set in the wikitext of an
+// article, either with the {{Short description}} template or with the
+// {{SHORTDESC:}} magic word. As in MediaWiki, a later short description
+// replaces an earlier one unless it has the noreplace flag. It reports false
+// if the wikitext has no short description or if it is explicitly "none".
+func ShortDescription(text string) (string, bool) {
+	var (
+		description string
+		found       bool
+	)
+
+	for _, template := range Templates(stripIgnored(text)) {
+		value, noreplace, ok := shortDescriptionOf(template)
+		if !ok || (found && noreplace) {
+			continue
+		}
+
+		description, found = value, true
+	}
+
+	if !found || description == "" || strings.EqualFold(description, "none") {
+		return "", false
+	}
+
+	return description, true
+}
+
+// Templates returns the contents, without the enclosing braces, of every
+// top-level template in text, in order of appearance. Templates nested inside
+// other templates are part of the contents of the outer template. An
+// unterminated template ends the scan.
+func Templates(text string) []string {
+	var templates []string
+
+	for i := 0; i < len(text); {
+		if !strings.HasPrefix(text[i:], "{{") {
+			i++
+
+			continue
+		}
+
+		end := closingBraces(text, i)
+		if end < 0 {
+			break
+		}
+
+		templates = append(templates, text[i+2:end])
+		i = end + 2
+	}
+
+	return templates
+}
+
+// closingBraces returns the index of the "}}" closing the template opened at
+// start, or -1 if the template is not terminated.
+func closingBraces(text string, start int) int {
+	depth := 0
+
+	for i := start; i < len(text)-1; {
+		switch {
+		case text[i] == '{' && text[i+1] == '{':
+			depth++
+			i += 2
+		case text[i] == '}' && text[i+1] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+			i += 2
+		default:
+			i++
+		}
+	}
+
+	return -1
+}
+
+// shortDescriptionOf returns the rendered short description set by template
+// and whether it has the noreplace flag. It reports false if the template is
+// neither {{Short description}} nor {{SHORTDESC:}}.
+func shortDescriptionOf(template string) (string, bool, bool) {
+	params := splitParams(template)
+
+	if name, value, ok := strings.Cut(params[0], ":"); ok && strings.EqualFold(strings.TrimSpace(name), "SHORTDESC") {
+		noreplace := false
+		for _, flag := range params[1:] {
+			if strings.TrimSpace(flag) == "noreplace" {
+				noreplace = true
+			}
+		}
+
+		return render(value), noreplace, true
+	}
+
+	if templateName(params[0]) != "short description" {
+		return "", false, false
+	}
+
+	positional, named := parseArgs(params[1:])
+
+	description, explicit := named["1"]
+	noreplace := named["2"] == "noreplace"
+
+	for i, arg := range positional {
+		if i == 0 && !explicit {
+			description = arg
+
+			continue
+		}
+
+		if arg == "noreplace" {
+			noreplace = true
+		}
+	}
+
+	return render(description), noreplace, true
+}
+
+// splitParams splits the contents of a template on the pipes that are not
+// inside a nested template or link. The first element is the template name.
+func splitParams(template string) []string {
+	var (
+		params       []string
+		braces, link int
+		last         int
+	)
+
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"):
+			braces++
+			i++
+		case strings.HasPrefix(template[i:], "}}") && braces > 0:
+			braces--
+			i++
+		case strings.HasPrefix(template[i:], "[["):
+			link++
+			i++
+		case strings.HasPrefix(template[i:], "]]") && link > 0:
+			link--
+			i++
+		case template[i] == '|' && braces == 0 && link == 0:
+			params = append(params, template[last:i])
+			last = i + 1
+		}
+	}
+
+	return append(params, template[last:])
+}
+
+// parseArgs sorts the arguments of a template into trimmed positional
+// arguments and named arguments. Explicitly numbered arguments such as 1=
+// are returned as named arguments.
+func parseArgs(args []string) ([]string, map[string]string) {
+	var positional []string
+	named := make(map[string]string)
+
+	for _, arg := range args {
+		if i := topLevelIndex(arg, '='); i >= 0 {
+			named[strings.TrimSpace(arg[:i])] = strings.TrimSpace(arg[i+1:])
+
+			continue
+		}
+
+		positional = append(positional, strings.TrimSpace(arg))
+	}
+
+	return positional, named
+}
+
+// topLevelIndex returns the index of the first b in s that is not inside a
+// nested template or link, or -1.
+func topLevelIndex(s string, b byte) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"), strings.HasPrefix(s[i:], "[["):
+			depth++
+			i++
+		case strings.HasPrefix(s[i:], "}}"), strings.HasPrefix(s[i:], "]]"):
+			depth--
+			i++
+		case s[i] == b && depth == 0:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// templateName normalizes a template name the way MediaWiki compares them:
+// case-insensitively, with underscores and runs of spaces as single spaces
+// and without the Template: namespace.
+func templateName(name string) string {
+	name = strings.ToLower(whitespaceRe.ReplaceAllString(strings.ReplaceAll(name, "_", " "), " "))
+	name = strings.TrimSpace(name)
+
+	return strings.TrimSpace(strings.TrimPrefix(name, "template:"))
+}
+
+// render turns a wikitext fragment into plain text: nested templates are
+// expanded as far as they can be without MediaWiki, links are replaced by
+// their labels and formatting is dropped.
+func render(text string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], "{{") {
+			if end := closingBraces(text, i); end >= 0 {
+				b.WriteString(renderTemplate(text[i+2 : end]))
+				i = end + 2
+
+				continue
+			}
+		}
+
+		b.WriteByte(text[i])
+		i++
+	}
+
+	text = linkRe.ReplaceAllStringFunc(b.String(), func(link string) string {
+		match := linkRe.FindStringSubmatch(link)
+		if match[2] != "" {
+			return match[2]
+		}
+
+		return match[1]
+	})
+	text = externalRe.ReplaceAllString(text, "$1")
+	text = strings.ReplaceAll(text, "'''", "")
+	text = strings.ReplaceAll(text, "''", "")
+	text = tagRe.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(text, " "))
+}
+
+// inlineTemplates are common templates that expand to fixed text.
+var inlineTemplates = map[string]string{
+	"!":            "|",
+	"=":            "=",
+	"ndash":        "–",
+	"mdash":        "—",
+	"snd":          " – ",
+	"spnd":         " – ",
+	"sndash":       " – ",
+	"spaced ndash": " – ",
+	"nbsp":         " ",
+}
+
+// formattingTemplates are common templates that only format the text of
+// their last positional argument, which is what they display.
+var formattingTemplates = map[string]bool{
+	"lang":     true,
+	"transl":   true,
+	"nowrap":   true,
+	"nobr":     true,
+	"nobreak":  true,
+	"noitalic": true,
+	"em":       true,
+	"strong":   true,
+	"small":    true,
+	"smaller":  true,
+}
+
+// renderTemplate renders a template nested in a short description. Templates
+// that expand to fixed text are replaced by it and formatting templates such
+// as {{lang|fr|...}} or {{nowrap|...}} by their last positional argument. Any
+// other template, such as {{convert|5|km}}, cannot be expanded without
+// MediaWiki and is dropped.
+func renderTemplate(template string) string {
+	params := splitParams(template)
+	name := templateName(params[0])
+
+	if text, ok := inlineTemplates[name]; ok {
+		return text
+	}
+
+	positional, _ := parseArgs(params[1:])
+	if len(positional) == 0 {
+		return ""
+	}
+
+	if name == "circa" || name == "c." {
+		return "c. " + render(positional[0])
+	}
+
+	if !formattingTemplates[name] {
+		return ""
+	}
+
+	return render(positional[len(positional)-1])
+}
@@ -0,0 +1,54 @@
+package wikitext_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/youssef1337/wikipedia-api/internal/wikitext"
+)
+
+// fixtures returns a table entry for every testdata/<name>.wikitext file. The
+// expected short description is in testdata/<name>.golden, which is empty if
+// the wikitext has none.
+func fixtures() []TableEntry {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.wikitext"))
+	if err != nil {
+		panic(err)
+	}
+
+	var entries []TableEntry
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".wikitext")
+		entries = append(entries, Entry(name, name))
+	}
+
+	return entries
+}
+
+var _ = Describe("ShortDescription", func() {
+	DescribeTable("extracting the short description from wikitext",
+		func(name string) {
+			text, err := os.ReadFile(filepath.Join("testdata", name+".wikitext"))
+			Expect(err).NotTo(HaveOccurred())
+
+			golden, err := os.ReadFile(filepath.Join("testdata", name+".golden"))
+			Expect(err).NotTo(HaveOccurred())
+
+			description, ok := wikitext.ShortDescription(string(text))
+
+			Expect(description).To(Equal(string(golden)))
+			Expect(ok).To(Equal(len(golden) > 0))
+		},
+		fixtures(),
+	)
+})
+
+func TestWikitext(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wikitext Suite")
+}
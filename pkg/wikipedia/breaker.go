@@ -0,0 +1,183 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen fails every request without sending it.
+	BreakerOpen
+
+	// BreakerHalfOpen lets a single probe request through to find out
+	// whether the upstream has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerError is returned instead of sending a request while the
+// circuit breaker is open.
+type CircuitBreakerError struct {
+	// RetryAfter is how long until the breaker lets a request through again.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitBreakerError) Error() string {
+	return fmt.Sprintf("wikipedia: circuit breaker is open, retry after %s", e.RetryAfter)
+}
+
+// CircuitBreaker stops sending requests to an upstream that keeps failing.
+// After Threshold consecutive failures it opens and fails requests right away
+// for Cooldown. It then lets a single probe request through: if it succeeds,
+// the breaker closes again, otherwise it stays open for another Cooldown.
+// Network errors, timeouts, 429 and 5xx responses count as failures. A
+// CircuitBreaker is safe for concurrent use and may be shared by clients.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a closed circuit breaker that opens after
+// threshold consecutive failures and stays open for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// WithCircuitBreaker guards the upstream requests of the client with a
+// circuit breaker.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+
+	return b.state
+}
+
+// allow reports whether a request may be sent. If it may not, it returns a
+// *CircuitBreakerError. Otherwise it reports whether the request is the probe
+// of a half-open breaker. Every allowed request must be followed by a call to
+// done with that flag.
+func (b *CircuitBreaker) allow() (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		remaining := b.cooldown - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return false, &CircuitBreakerError{RetryAfter: remaining}
+		}
+
+		b.state = BreakerHalfOpen
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.probing {
+			return false, &CircuitBreakerError{RetryAfter: time.Second}
+		}
+
+		b.probing = true
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// done records the outcome of a request let through by allow. Only the probe
+// decides whether a half-open breaker closes or opens again; requests that
+// were let through before the breaker opened and finish afterwards are
+// ignored.
+func (b *CircuitBreaker) done(probe bool, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	} else if b.state != BreakerClosed {
+		return
+	}
+
+	switch {
+	case err == nil:
+		b.failures = 0
+		b.state = BreakerClosed
+	case isUpstreamFailure(err):
+		b.failures++
+		if probe || b.failures >= b.threshold {
+			b.state = BreakerOpen
+			b.openedAt = b.now()
+		}
+	default:
+		// The request was given up before it reached the upstream, e.g.
+		// because the caller went away, which says nothing about the
+		// upstream. A half-open breaker lets the next request probe.
+	}
+}
+
+// isUpstreamFailure reports whether err shows that the upstream is unable to
+// serve requests.
+func isUpstreamFailure(err error) bool {
+	var (
+		statusErr    *StatusError
+		transportErr *transportError
+	)
+
+	switch {
+	case errors.As(err, &statusErr):
+		return isTransientStatus(statusErr.StatusCode) || statusErr.StatusCode >= 500
+	case errors.As(err, &transportErr):
+		return !errors.Is(err, context.Canceled)
+	default:
+		return false
+	}
+}
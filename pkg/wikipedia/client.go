@@ -0,0 +1,533 @@
+// Package wikipedia is a small client for the MediaWiki action API used to
+// look up the short description of Wikipedia articles.
+package wikipedia
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/youssef1337/wikipedia-api/internal/wikitext"
+)
+
+// tracer records the spans of the client with the global OpenTelemetry tracer
+// provider, which discards them unless the program has installed one.
+var tracer = otel.Tracer("github.com/youssef1337/wikipedia-api/pkg/wikipedia")
+
+// DefaultBaseURL is the MediaWiki action API endpoint used when no other base
+// URL is configured. The {lang} placeholder is replaced by the language of the
+// client.
+const DefaultBaseURL = "https://{lang}.wikipedia.org/w/api.php"
+
+// DefaultLanguage is the language of a Client unless changed with
+// WithLanguage or ForLanguage.
+const DefaultLanguage = "en"
+
+// DefaultWikidataURL is the Wikidata action API endpoint used to look up the
+// descriptions of articles that have no short description on Wikipedia.
+const DefaultWikidataURL = "https://www.wikidata.org/w/api.php"
+
+// DefaultUserAgent is the User-Agent header sent with every upstream request
+// unless changed with WithUserAgent. Wikimedia asks API clients to identify
+// themselves with contact information, so services should set their own.
+const DefaultUserAgent = "wikipedia-api (https://github.com/youssef1337/wikipedia-api)"
+
+// MaxTitlesPerQuery is the maximum number of titles MediaWiki accepts in a
+// single query from clients without the apihighlimits right.
+const MaxTitlesPerQuery = 50
+
+// Client talks to the MediaWiki action API. The zero value is not usable, use
+// New to create one.
+type Client struct {
+	baseURL     string
+	wikidataURL string
+	lang        string
+	httpClient  *http.Client
+	userAgent   string
+	retryPolicy RetryPolicy
+	breaker     *CircuitBreaker
+	limiter     *limiter
+	maxLag      int
+	onWarning   func(Warning)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sets the MediaWiki action API endpoint, e.g.
+// https://{lang}.wikipedia.org/w/api.php. An optional {lang} placeholder is
+// replaced by the language of the client.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithWikidataURL sets the Wikidata action API endpoint.
+func WithWikidataURL(wikidataURL string) Option {
+	return func(c *Client) {
+		c.wikidataURL = wikidataURL
+	}
+}
+
+// WithLanguage sets the language edition of Wikipedia queried by the client,
+// e.g. "de" for the German Wikipedia.
+func WithLanguage(lang string) Option {
+	return func(c *Client) {
+		c.lang = lang
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for upstream requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every upstream request.
+// It should name the service and say how to contact its operators, e.g.
+// "my-service/1.0 (https://example.com/contact)".
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithMaxLag sets the maxlag parameter sent with every upstream request, in
+// seconds. MediaWiki refuses requests while its database replicas lag behind
+// by more than that, and the client then backs off for the time MediaWiki
+// asks for. Zero leaves the parameter out.
+func WithMaxLag(seconds int) Option {
+	return func(c *Client) {
+		c.maxLag = seconds
+	}
+}
+
+// WithWarningHandler sets a function that is called with every warning the
+// MediaWiki API reports, e.g. to log them. Warnings are ignored by default.
+func WithWarningHandler(handler func(Warning)) Option {
+	return func(c *Client) {
+		c.onWarning = handler
+	}
+}
+
+// New returns a Client configured with the given options.
+func New(opts ...Option) *Client {
+	c := &Client{
+		baseURL:     DefaultBaseURL,
+		wikidataURL: DefaultWikidataURL,
+		lang:        DefaultLanguage,
+		httpClient:  &http.Client{},
+		userAgent:   DefaultUserAgent,
+		limiter:     newLimiter(0),
+		retryPolicy: DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// ForLanguage returns a copy of the client that queries the given language
+// edition of Wikipedia.
+func (c *Client) ForLanguage(lang string) *Client {
+	clone := *c
+	clone.lang = lang
+
+	return &clone
+}
+
+// Language returns the language edition of Wikipedia queried by the client.
+func (c *Client) Language() string {
+	return c.lang
+}
+
+// ShortDescription looks up the short description of the article with the
+// given title, following redirects and title normalization. The description
+// is taken from the {{Short description}} template in the wikitext of the
+// article, falling back to the page description and then to the description
+// of the Wikidata entity of the article. It returns ErrMissing if the article
+// does not exist, ErrNoDescription if none of these has a description and an
+// *InvalidTitleError if title is not a valid page title.
+func (c *Client) ShortDescription(ctx context.Context, title string) (*Result, error) {
+	title, err := NormalizeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	params := descriptionQuery(title)
+	params.Set("rvlimit", "1")
+
+	var response response
+	if err := c.get(ctx, c.endpoint(), params, &response); err != nil {
+		return nil, err
+	}
+
+	resolved, err := response.resolveSingle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.describe(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ShortDescription == "" {
+		err := c.fillFromWikidata(ctx, []*Result{result}, []string{resolved.page.PageProps.WikibaseItem})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result.ShortDescription == "" {
+		return nil, ErrNoDescription
+	}
+
+	return result, nil
+}
+
+// ShortDescriptions looks up the short descriptions of many articles at once,
+// in the same way as ShortDescription. Titles are deduplicated and sent to
+// MediaWiki in multi-title queries of up to MaxTitlesPerQuery titles. The
+// returned slice holds one BatchResult per input title, in input order.
+func (c *Client) ShortDescriptions(ctx context.Context, titles []string) ([]BatchResult, error) {
+	results := make([]BatchResult, len(titles))
+	normalized := make([]string, len(titles))
+
+	var unique []string
+	seen := make(map[string]bool, len(titles))
+	for i, title := range titles {
+		results[i].Query = title
+
+		normalized[i], results[i].Err = NormalizeTitle(title)
+		if results[i].Err == nil && !seen[normalized[i]] {
+			seen[normalized[i]] = true
+			unique = append(unique, normalized[i])
+		}
+	}
+
+	pages := make(map[string]resolvedPage, len(unique))
+	for start := 0; start < len(unique); start += MaxTitlesPerQuery {
+		end := start + MaxTitlesPerQuery
+		if end > len(unique) {
+			end = len(unique)
+		}
+
+		if err := c.queryPages(ctx, unique[start:end], pages); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		pending []*Result
+		items   []string
+	)
+
+	for i := range titles {
+		if results[i].Err != nil {
+			continue
+		}
+
+		resolved, ok := pages[normalized[i]]
+		if !ok {
+			results[i].Err = ErrMissing
+
+			continue
+		}
+
+		if err := resolved.err(titles[i]); err != nil {
+			results[i].Err = err
+
+			continue
+		}
+
+		results[i].Result, results[i].Err = c.describe(ctx, resolved)
+		if results[i].Err == nil && results[i].Result.ShortDescription == "" {
+			pending = append(pending, results[i].Result)
+			items = append(items, resolved.page.PageProps.WikibaseItem)
+		}
+	}
+
+	if err := c.fillFromWikidata(ctx, pending, items); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Result != nil && results[i].Result.ShortDescription == "" {
+			results[i].Result, results[i].Err = nil, ErrNoDescription
+		}
+	}
+
+	return results, nil
+}
+
+// queryPages fetches every title in a single query, following redirects, and
+// stores the returned pages in pages, keyed by the title as it was requested.
+// When the wikitext of every page does not fit in a single response, MediaWiki
+// leaves the revisions of some pages out and the query is continued until
+// every page has them.
+func (c *Client) queryPages(ctx context.Context, titles []string, pages map[string]resolvedPage) error {
+	var merged response
+	if err := c.get(ctx, c.endpoint(), descriptionQuery(strings.Join(titles, "|")), &merged); err != nil {
+		return err
+	}
+
+	for len(merged.Continue) > 0 {
+		continued := descriptionQuery(strings.Join(titles, "|"))
+		for key, value := range merged.Continue {
+			continued.Set(key, value)
+		}
+
+		var next response
+		if err := c.get(ctx, c.endpoint(), continued, &next); err != nil {
+			return err
+		}
+
+		if reflect.DeepEqual(next.Continue, merged.Continue) {
+			return fmt.Errorf("%w: the query continues where it started", ErrUnexpectedResponse)
+		}
+
+		merged.merge(&next)
+		merged.Continue = next.Continue
+	}
+
+	for _, title := range titles {
+		if resolved, ok := merged.resolve(title); ok {
+			pages[title] = resolved
+		}
+	}
+
+	return nil
+}
+
+// descriptionQuery returns the parameters of a query for the latest revision,
+// the page description and the Wikidata item of the given titles.
+func descriptionQuery(titles string) url.Values {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("prop", "revisions|description|pageprops")
+	params.Set("titles", titles)
+	params.Set("redirects", "1")
+	params.Set("rvprop", "content")
+	params.Set("descprefersource", "local")
+	params.Set("ppprop", "wikibase_item")
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	return params
+}
+
+// describe builds the result for a page returned by a description query. The
+// short description is taken from the wikitext or else from the page
+// description; it is left empty if the page has neither.
+func (c *Client) describe(ctx context.Context, resolved resolvedPage) (*Result, error) {
+	p := resolved.page
+
+	if p.Missing || p.Invalid {
+		return nil, ErrMissing
+	}
+
+	result := &Result{
+		Language:  c.lang,
+		Title:     p.Title,
+		PageID:    p.PageID,
+		Redirects: resolved.redirects,
+	}
+
+	if len(p.Revisions) > 0 {
+		_, span := tracer.Start(ctx, "wikitext.ShortDescription")
+		shortDescription, ok := wikitext.ShortDescription(p.Revisions[0].Content)
+		span.SetAttributes(
+			attribute.Int("wikitext.length", len(p.Revisions[0].Content)),
+			attribute.Bool("wikitext.found", ok),
+		)
+		span.End()
+
+		if ok {
+			result.ShortDescription = shortDescription
+			result.Source = SourceWikitext
+
+			return result, nil
+		}
+	}
+
+	if p.Description != "" {
+		result.ShortDescription = p.Description
+		result.Source = SourceDescription
+
+		// A central description is the one of the Wikidata entity.
+		if p.DescriptionSource == "central" {
+			result.Source = SourceWikidata
+		}
+	}
+
+	return result, nil
+}
+
+// get performs a GET request against an action API endpoint and decodes the
+// JSON body into v. It returns an *APIError if MediaWiki reported an error in
+// the body and passes any warnings to the warning handler of the client.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, v apiResponse) error {
+	if c.maxLag > 0 {
+		params.Set("maxlag", strconv.Itoa(c.maxLag))
+	}
+
+	body, err := c.fetch(ctx, endpoint+"?"+params.Encode())
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: decoding: %v", ErrUnexpectedResponse, err)
+	}
+
+	envelope := v.apiEnvelope()
+	if envelope.Error != nil {
+		return envelope.Error
+	}
+
+	if c.onWarning != nil {
+		modules := make([]string, 0, len(envelope.Warnings))
+		for module := range envelope.Warnings {
+			modules = append(modules, module)
+		}
+
+		sort.Strings(modules)
+		for _, module := range modules {
+			c.onWarning(Warning{Module: module, Text: envelope.Warnings[module].Warnings})
+		}
+	}
+
+	return nil
+}
+
+// fetch GETs rawURL, retrying transient failures according to the retry
+// policy of the client, and returns the body of the successful response. No
+// attempt is made while the circuit breaker of the client is open.
+func (c *Client) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		probe, err := c.breaker.allow()
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := c.fetchOnce(ctx, rawURL)
+		c.breaker.done(probe, err)
+		if err == nil {
+			return body, nil
+		}
+
+		delay, retry := c.retryPolicy.retryDelay(ctx, attempt, err)
+		if !retry {
+			return nil, err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchOnce GETs rawURL and returns the body of the response, waiting for the
+// limiter of the client first.
+func (c *Client) fetchOnce(ctx context.Context, rawURL string) ([]byte, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	defer c.limiter.release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &transportError{err: err}
+	}
+
+	defer resp.Body.Close()
+
+	// MediaWiki answers a request refused because of maxlag with a 200.
+	if resp.Header.Get("MediaWiki-API-Error") == "maxlag" {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		c.limiter.hold(retryAfter)
+
+		return nil, &MaxLagError{RetryAfter: retryAfter}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &transportError{err: err}
+	}
+
+	// Proxies and caches in front of MediaWiki may drop the header, leaving
+	// only the error in the body.
+	if lag, ok := maxLagBody(body); ok {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		if retryAfter == 0 {
+			retryAfter = lag
+		}
+
+		c.limiter.hold(retryAfter)
+
+		return nil, &MaxLagError{RetryAfter: retryAfter}
+	}
+
+	return body, nil
+}
+
+// maxLagBody reports whether body is a maxlag error and returns the lag it
+// reports, rounded up to the second.
+func maxLagBody(body []byte) (time.Duration, bool) {
+	if !bytes.Contains(body, []byte(`"maxlag"`)) {
+		return 0, false
+	}
+
+	var envelope struct {
+		Error *struct {
+			Code string  `json:"code"`
+			Lag  float64 `json:"lag"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil || envelope.Error.Code != "maxlag" {
+		return 0, false
+	}
+
+	return time.Duration(math.Ceil(envelope.Error.Lag)) * time.Second, true
+}
+
+// endpoint returns the action API endpoint for the language of the client.
+func (c *Client) endpoint() string {
+	return strings.ReplaceAll(c.baseURL, "{lang}", c.lang)
+}
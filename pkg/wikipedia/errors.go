@@ -0,0 +1,76 @@
+package wikipedia
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMissing is returned when the requested article does not exist.
+	ErrMissing = errors.New("wikipedia: article not found")
+
+	// ErrNoDescription is returned when the article exists but has no short
+	// description.
+	ErrNoDescription = errors.New("wikipedia: no short description found")
+
+	// ErrNoSummary is returned when the article exists but has no lead
+	// section.
+	ErrNoSummary = errors.New("wikipedia: no summary found")
+
+	// ErrUnexpectedResponse is returned when a response of the MediaWiki
+	// API cannot be decoded or lacks data it should contain.
+	ErrUnexpectedResponse = errors.New("wikipedia: unexpected response")
+)
+
+// APIError is an error reported by the MediaWiki API in the body of a
+// response, usually sent with a 200 HTTP status code. The codes are listed at
+// https://www.mediawiki.org/wiki/API:Errors_and_warnings.
+type APIError struct {
+	Code string `json:"code"`
+	Info string `json:"info"`
+
+	// ID is the entity the error is about, set by Wikibase for errors such
+	// as no-such-entity.
+	ID string `json:"id,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wikipedia: api error %s: %s", e.Code, e.Info)
+}
+
+// Warning is a warning reported by the MediaWiki API along with an otherwise
+// successful response, e.g. about a deprecated or unrecognized parameter.
+type Warning struct {
+	// Module is the name of the API module that issued the warning, e.g.
+	// "main" or "query".
+	Module string
+
+	Text string
+}
+
+// StatusError is returned when the MediaWiki API responds with a non-200 HTTP
+// status code.
+type StatusError struct {
+	StatusCode int
+
+	// RetryAfter is the delay asked for by the Retry-After header of the
+	// response, zero if it had none.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("wikipedia: unexpected http status %d", e.StatusCode)
+}
+
+// MaxLagError is returned when MediaWiki refuses a request because its
+// database replicas lag behind by more than the maxlag parameter allows.
+type MaxLagError struct {
+	// RetryAfter is how long MediaWiki asks clients to wait before sending
+	// further requests.
+	RetryAfter time.Duration
+}
+
+func (e *MaxLagError) Error() string {
+	return fmt.Sprintf("wikipedia: database replicas are lagged, retry after %s", e.RetryAfter)
+}
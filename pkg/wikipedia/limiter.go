@@ -0,0 +1,120 @@
+package wikipedia
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithMaxConcurrency limits the number of upstream requests the client and
+// its copies made with ForLanguage send at the same time. The API etiquette of
+// Wikimedia asks for requests to be sent one at a time. Zero or less removes
+// the limit.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		c.limiter = newLimiter(n)
+	}
+}
+
+// limiter paces the upstream requests of a client. It bounds how many are in
+// flight at once and holds all of them back while MediaWiki asks the client
+// to back off.
+type limiter struct {
+	slots chan struct{}
+	pause *pause
+}
+
+// pause is the time until which MediaWiki asked the client to back off.
+type pause struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// newLimiter returns a limiter allowing n requests at once, or any number if n
+// is zero or less.
+func newLimiter(n int) *limiter {
+	l := &limiter{pause: &pause{}}
+	if n > 0 {
+		l.slots = make(chan struct{}, n)
+	}
+
+	return l
+}
+
+// WithoutConcurrencyLimit returns a copy of the client whose requests do not
+// wait for the slots of WithMaxConcurrency, e.g. for health checks that must
+// not queue behind lookups. They are still held back while MediaWiki asks the
+// client to back off.
+func (c *Client) WithoutConcurrencyLimit() *Client {
+	clone := *c
+	clone.limiter = &limiter{pause: c.limiter.pause}
+
+	return &clone
+}
+
+// acquire waits until a request may be sent or ctx is done. It returns a
+// *MaxLagError right away if the client is held back for longer than the
+// deadline of ctx. Every successful call must be followed by a call to
+// release.
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		if err := l.waitPause(ctx); err != nil {
+			return err
+		}
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// The request holding the slot before may have been told to back
+		// off while this one was waiting for it.
+		if l.pausedFor() <= 0 {
+			return nil
+		}
+
+		l.release()
+	}
+}
+
+// waitPause waits until the client is no longer held back.
+func (l *limiter) waitPause(ctx context.Context) error {
+	wait := l.pausedFor()
+	if wait <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return &MaxLagError{RetryAfter: wait}
+	}
+
+	return sleep(ctx, wait)
+}
+
+// pausedFor returns how long the client is still held back.
+func (l *limiter) pausedFor() time.Duration {
+	l.pause.mu.Lock()
+	defer l.pause.mu.Unlock()
+
+	return time.Until(l.pause.until)
+}
+
+// release frees the slot taken by acquire.
+func (l *limiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// hold holds back all requests for d.
+func (l *limiter) hold(d time.Duration) {
+	l.pause.mu.Lock()
+	defer l.pause.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.pause.until) {
+		l.pause.until = until
+	}
+}
@@ -0,0 +1,186 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how upstream requests that failed with a transient
+// error are retried. Network errors, maxlag errors and the 429, 502, 503 and
+// 504 HTTP status codes are transient. Retries are delayed with exponential backoff and
+// jitter, or by the Retry-After header of the response if it asks for longer,
+// and are never attempted if the wait would exceed MaxRetryAfter or the
+// deadline of the request context.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// One or less disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for every
+	// subsequent retry.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff delay. A longer Retry-After is still
+	// honored.
+	MaxDelay time.Duration
+
+	// MaxRetryAfter is the longest Retry-After that is waited for. A
+	// request asked to wait longer is not retried, so that it does not hold
+	// its caller and an upstream slot for as long as the upstream says,
+	// even without a deadline. Zero disables the limit.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of a Client unless changed with
+// WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   3,
+	BaseDelay:     200 * time.Millisecond,
+	MaxDelay:      2 * time.Second,
+	MaxRetryAfter: 5 * time.Second,
+}
+
+// WithRetryPolicy sets the policy used to retry upstream requests that failed
+// with a transient error.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// transportError wraps an error that occurred while sending a request or
+// reading its response, before any HTTP status could be acted upon.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// retryDelay returns how long to wait before retrying after attempt failed
+// with err. It reports false if the request should not be retried, because
+// the error is not transient, the attempts are used up or the wait would
+// exceed p.MaxRetryAfter or the deadline of ctx.
+func (p RetryPolicy) retryDelay(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	var (
+		retryAfter   time.Duration
+		statusErr    *StatusError
+		maxLagErr    *MaxLagError
+		transportErr *transportError
+	)
+
+	switch {
+	case errors.As(err, &statusErr) && isTransientStatus(statusErr.StatusCode):
+		retryAfter = statusErr.RetryAfter
+	case errors.As(err, &maxLagErr):
+		retryAfter = maxLagErr.RetryAfter
+	case errors.As(err, &transportErr):
+	default:
+		return 0, false
+	}
+
+	if p.MaxRetryAfter > 0 && retryAfter > p.MaxRetryAfter {
+		return 0, false
+	}
+
+	delay := p.backoff(attempt)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// backoff returns the exponential backoff delay before retrying after
+// attempt, with equal jitter: a random duration between half and all of it.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if delay <= 1 {
+		return delay
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(jitter(int64(delay-half)))
+}
+
+func isTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter returns the delay asked for by a Retry-After header, given
+// either in seconds or as an HTTP date, or zero if there is none.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever happens first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter returns a random number in [0, n).
+func jitter(n int64) int64 {
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+
+	return jitterRand.Int63n(n)
+}
@@ -0,0 +1,48 @@
+package wikipedia
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// SearchResults are the articles matching a full-text search.
+type SearchResults struct {
+	// Titles are the titles of the best matching articles, best first.
+	Titles []string
+
+	// Suggestion is MediaWiki's spelling suggestion for the search, empty if
+	// it has none.
+	Suggestion string
+}
+
+// Search runs a full-text search for query and returns the titles of up to
+// limit matching articles along with a spelling suggestion.
+func (c *Client) Search(ctx context.Context, query string, limit int) (*SearchResults, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("list", "search")
+	params.Set("srsearch", query)
+	params.Set("srnamespace", "0")
+	params.Set("srlimit", strconv.Itoa(limit))
+	params.Set("srinfo", "suggestion")
+	params.Set("srprop", "")
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	var response response
+	if err := c.get(ctx, c.endpoint(), params, &response); err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{
+		Titles:     make([]string, len(response.Query.Search)),
+		Suggestion: response.Query.SearchInfo.Suggestion,
+	}
+
+	for i, hit := range response.Query.Search {
+		results.Titles[i] = hit.Title
+	}
+
+	return results, nil
+}
@@ -0,0 +1,45 @@
+package wikipedia
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// SiteInfo is the general information MediaWiki reports about a wiki.
+type SiteInfo struct {
+	SiteName string `json:"sitename"`
+
+	// Generator is the MediaWiki version, e.g. MediaWiki 1.41.0-wmf.1.
+	Generator string `json:"generator"`
+}
+
+type siteInfoResponse struct {
+	envelope
+	Query struct {
+		General SiteInfo `json:"general"`
+	} `json:"query"`
+}
+
+// SiteInfo returns the general information about the wiki of the client. It is
+// one of the cheapest requests MediaWiki answers, which makes it suitable to
+// check that the wiki can be reached.
+func (c *Client) SiteInfo(ctx context.Context) (*SiteInfo, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("meta", "siteinfo")
+	params.Set("siprop", "general")
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	var response siteInfoResponse
+	if err := c.get(ctx, c.endpoint(), params, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Query.General.Generator == "" {
+		return nil, fmt.Errorf("%w: no general site information", ErrUnexpectedResponse)
+	}
+
+	return &response.Query.General, nil
+}
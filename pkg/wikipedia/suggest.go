@@ -0,0 +1,95 @@
+package wikipedia
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Suggestion is an article whose title starts with a searched prefix.
+type Suggestion struct {
+	Title            string
+	PageID           int
+	ShortDescription string
+
+	// Source tells where ShortDescription was taken from, empty if the
+	// article has no description.
+	Source Source
+}
+
+// Suggest returns up to limit articles whose titles start with prefix, ranked
+// by MediaWiki's prefix search, together with their descriptions. Redirects
+// are resolved to their target, so every article is suggested at most once.
+func (c *Client) Suggest(ctx context.Context, prefix string, limit int) ([]Suggestion, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("generator", "prefixsearch")
+	params.Set("gpssearch", prefix)
+	params.Set("gpsnamespace", "0")
+	params.Set("gpslimit", strconv.Itoa(limit))
+	params.Set("prop", "description")
+	params.Set("descprefersource", "local")
+	params.Set("redirects", "1")
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	var response response
+	if err := c.get(ctx, c.endpoint(), params, &response); err != nil {
+		return nil, err
+	}
+
+	// Pages reached through a redirect carry no rank of their own, the
+	// redirect does.
+	rank := make(map[string]int, len(response.Query.Pages))
+	for _, p := range response.Query.Pages {
+		if p.Index > 0 {
+			rank[p.Title] = p.Index
+		}
+	}
+
+	for _, rd := range response.Query.Redirects {
+		if index, ok := rank[rd.To]; rd.Index > 0 && (!ok || rd.Index < index) {
+			rank[rd.To] = rd.Index
+		}
+	}
+
+	pages := make([]page, 0, len(response.Query.Pages))
+	for _, p := range response.Query.Pages {
+		if !p.Missing && !p.Invalid {
+			pages = append(pages, p)
+		}
+	}
+
+	rankOf := func(p page) int {
+		if index, ok := rank[p.Title]; ok {
+			return index
+		}
+
+		return math.MaxInt
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return rankOf(pages[i]) < rankOf(pages[j])
+	})
+
+	suggestions := make([]Suggestion, len(pages))
+	for i, p := range pages {
+		suggestions[i] = Suggestion{
+			Title:            p.Title,
+			PageID:           p.PageID,
+			ShortDescription: p.Description,
+		}
+
+		switch {
+		case p.Description == "":
+		case p.DescriptionSource == "central":
+			suggestions[i].Source = SourceWikidata
+		default:
+			suggestions[i].Source = SourceDescription
+		}
+	}
+
+	return suggestions, nil
+}
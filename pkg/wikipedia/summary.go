@@ -0,0 +1,90 @@
+package wikipedia
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Summary is the plain-text lead section of an article.
+type Summary struct {
+	Language  string
+	Title     string
+	PageID    int
+	Redirects []Redirect
+	Extract   string
+}
+
+// MaxSummarySentences and MaxSummaryChars are the largest lengths the
+// TextExtracts API accepts for a summary.
+const (
+	MaxSummarySentences = 10
+	MaxSummaryChars     = 1200
+)
+
+// SummaryOptions limits the length of a summary. At most one of Sentences and
+// Chars may be set; if both are zero the whole lead section is returned.
+type SummaryOptions struct {
+	// Sentences is the maximum number of sentences, at most
+	// MaxSummarySentences.
+	Sentences int
+
+	// Chars is the approximate maximum number of characters, at most
+	// MaxSummaryChars.
+	Chars int
+}
+
+// Summary looks up the plain-text lead section of the article with the given
+// title using the TextExtracts API, following redirects and title
+// normalization. It returns ErrMissing if the article does not exist,
+// ErrNoSummary if the article has no lead section and an *InvalidTitleError
+// if title is not a valid page title.
+func (c *Client) Summary(ctx context.Context, title string, opts SummaryOptions) (*Summary, error) {
+	title, err := NormalizeTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("prop", "extracts")
+	params.Set("titles", title)
+	params.Set("redirects", "1")
+	params.Set("exintro", "1")
+	params.Set("explaintext", "1")
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	if opts.Sentences > 0 {
+		params.Set("exsentences", strconv.Itoa(opts.Sentences))
+	} else if opts.Chars > 0 {
+		params.Set("exchars", strconv.Itoa(opts.Chars))
+	}
+
+	var response response
+	if err := c.get(ctx, c.endpoint(), params, &response); err != nil {
+		return nil, err
+	}
+
+	resolved, err := response.resolveSingle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	p := resolved.page
+	if p.Missing || p.Invalid {
+		return nil, ErrMissing
+	}
+
+	if p.Extract == "" {
+		return nil, ErrNoSummary
+	}
+
+	return &Summary{
+		Language:  c.lang,
+		Title:     p.Title,
+		PageID:    p.PageID,
+		Redirects: resolved.redirects,
+		Extract:   p.Extract,
+	}, nil
+}
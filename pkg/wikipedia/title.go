@@ -0,0 +1,92 @@
+package wikipedia
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxTitleLength is the maximum length of a MediaWiki page title in bytes.
+const MaxTitleLength = 255
+
+// Rules broken by invalid titles, reported in InvalidTitleError.Rule.
+const (
+	RuleEmpty            = "empty"
+	RuleMaxLength        = "max_length"
+	RuleInvalidUTF8      = "invalid_utf8"
+	RuleControlCharacter = "control_character"
+	RuleIllegalCharacter = "illegal_character"
+	RulePercentEncoding  = "percent_encoding"
+
+	// RuleInterwiki is only reported once MediaWiki has recognized the
+	// interwiki prefix of a title, since NormalizeTitle does not know the
+	// prefixes of a wiki.
+	RuleInterwiki = "interwiki"
+)
+
+// illegalTitleCharacters are the printable ASCII characters MediaWiki does
+// not allow in page titles.
+const illegalTitleCharacters = "#<>[]|{}"
+
+// InvalidTitleError is returned for titles MediaWiki would reject.
+type InvalidTitleError struct {
+	Title string
+
+	// Rule is the rule broken by the title, one of the Rule constants.
+	Rule string
+
+	// Detail is a human readable description of the problem.
+	Detail string
+}
+
+func (e *InvalidTitleError) Error() string {
+	return fmt.Sprintf("wikipedia: invalid title %q: %s", e.Title, e.Detail)
+}
+
+// NormalizeTitle applies Unicode NFC normalization to title and checks that
+// MediaWiki would accept it as a page title. It returns the normalized title
+// or an *InvalidTitleError naming the broken rule.
+func NormalizeTitle(title string) (string, error) {
+	invalid := func(rule, detail string) error {
+		return &InvalidTitleError{Title: title, Rule: rule, Detail: detail}
+	}
+
+	if !utf8.ValidString(title) {
+		return "", invalid(RuleInvalidUTF8, "The title is not valid UTF-8.")
+	}
+
+	normalized := norm.NFC.String(title)
+
+	if strings.TrimSpace(normalized) == "" {
+		return "", invalid(RuleEmpty, "The title is empty.")
+	}
+
+	if len(normalized) > MaxTitleLength {
+		return "", invalid(RuleMaxLength, fmt.Sprintf("The title is longer than %d bytes.", MaxTitleLength))
+	}
+
+	for i, r := range normalized {
+		switch {
+		case unicode.IsControl(r):
+			return "", invalid(RuleControlCharacter, fmt.Sprintf("The title contains the control character %U.", r))
+		case strings.ContainsRune(illegalTitleCharacters, r):
+			return "", invalid(RuleIllegalCharacter, fmt.Sprintf("The title contains the illegal character '%c'.", r))
+		case r == '%' && isPercentEncoded(normalized[i:]):
+			return "", invalid(RulePercentEncoding, "The title contains a percent-encoded character, decode it first.")
+		}
+	}
+
+	return normalized, nil
+}
+
+// isPercentEncoded reports whether s starts with a %XX escape sequence.
+func isPercentEncoded(s string) bool {
+	return len(s) >= 3 && isHex(s[1]) && isHex(s[2])
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,285 @@
+package wikipedia
+
+import "fmt"
+
+// Result is the outcome of a successful short description lookup.
+type Result struct {
+	// Language is the language edition of Wikipedia the article is from.
+	Language string
+
+	// Title is the canonical title of the article that was looked up,
+	// after normalization and redirects.
+	Title  string
+	PageID int
+
+	// Redirects is the chain of redirects that was followed to reach Title,
+	// empty if the requested title was not a redirect.
+	Redirects []Redirect
+
+	ShortDescription string
+
+	// Source tells where ShortDescription was taken from.
+	Source Source
+}
+
+// Source is where a short description was taken from.
+type Source string
+
+const (
+	// SourceWikitext is the {{Short description}} template in the wikitext
+	// of the article.
+	SourceWikitext Source = "wikitext"
+
+	// SourceDescription is the description property of the page.
+	SourceDescription Source = "description"
+
+	// SourceWikidata is the description of the Wikidata entity of the
+	// article.
+	SourceWikidata Source = "wikidata"
+)
+
+// Redirect is a single redirect followed while resolving a title.
+type Redirect struct {
+	From     string
+	To       string
+	Fragment string
+}
+
+// BatchResult is the outcome of looking up a single title as part of a
+// ShortDescriptions call. Err is ErrMissing, ErrNoDescription or an
+// *InvalidTitleError when the lookup did not produce a Result.
+type BatchResult struct {
+	Query  string
+	Result *Result
+	Err    error
+}
+
+// apiResponse is implemented by the types MediaWiki responses are decoded
+// into.
+type apiResponse interface {
+	apiEnvelope() *envelope
+}
+
+// envelope holds the parts of a response that MediaWiki may add to the
+// response of any module.
+type envelope struct {
+	Error    *APIError                 `json:"error"`
+	Warnings map[string]moduleWarnings `json:"warnings"`
+}
+
+func (e *envelope) apiEnvelope() *envelope {
+	return e
+}
+
+type moduleWarnings struct {
+	Warnings string `json:"warnings"`
+}
+
+type response struct {
+	envelope
+	Query query `json:"query"`
+
+	// Continue holds the parameters to send with the next request when
+	// MediaWiki could not fit every property of every page in the response.
+	Continue map[string]string `json:"continue"`
+}
+
+// merge adds the properties of the pages of a continued response to r.
+// MediaWiki repeats every page in a continued response, but only fills in
+// the properties it had no room for before.
+func (r *response) merge(next *response) {
+	for _, p := range next.Query.Pages {
+		i := r.pageIndex(p.Title)
+		if i < 0 {
+			r.Query.Pages = append(r.Query.Pages, p)
+
+			continue
+		}
+
+		existing := &r.Query.Pages[i]
+		if len(existing.Revisions) == 0 {
+			existing.Revisions = p.Revisions
+		}
+
+		if existing.Description == "" {
+			existing.Description = p.Description
+			existing.DescriptionSource = p.DescriptionSource
+		}
+
+		if existing.PageProps.WikibaseItem == "" {
+			existing.PageProps = p.PageProps
+		}
+	}
+}
+
+func (r *response) pageIndex(title string) int {
+	for i, p := range r.Query.Pages {
+		if p.Title == title {
+			return i
+		}
+	}
+
+	return -1
+}
+
+type query struct {
+	Normalized []normalization `json:"normalized"`
+	Redirects  []redirect      `json:"redirects"`
+	Pages      []page          `json:"pages"`
+	Search     []searchHit     `json:"search"`
+	SearchInfo searchInfo      `json:"searchinfo"`
+	Interwiki  []interwiki     `json:"interwiki"`
+}
+
+// interwiki is a requested title that starts with an interwiki prefix, such
+// as fr:Paris, and so names a page of another wiki.
+type interwiki struct {
+	Title  string `json:"title"`
+	Prefix string `json:"iw"`
+}
+
+type searchHit struct {
+	Ns     int    `json:"ns"`
+	Title  string `json:"title"`
+	PageID int    `json:"pageid"`
+}
+
+type searchInfo struct {
+	Suggestion string `json:"suggestion"`
+}
+
+type normalization struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type redirect struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ToFragment string `json:"tofragment"`
+	Index      int    `json:"index"`
+}
+
+type page struct {
+	PageID            int        `json:"pageid"`
+	Ns                int        `json:"ns"`
+	Title             string     `json:"title"`
+	Revisions         []revision `json:"revisions"`
+	Description       string     `json:"description"`
+	DescriptionSource string     `json:"descriptionsource"`
+	Extract           string     `json:"extract"`
+	Index             int        `json:"index"`
+	PageProps         pageProps  `json:"pageprops"`
+	Missing           bool       `json:"missing"`
+	Invalid           bool       `json:"invalid"`
+}
+
+type pageProps struct {
+	WikibaseItem string `json:"wikibase_item"`
+}
+
+type revision struct {
+	Content string `json:"content"`
+}
+
+// resolvedPage is a page together with the redirects followed to reach it.
+// If the title names a page of another wiki, interwiki holds its prefix
+// instead.
+type resolvedPage struct {
+	page      page
+	redirects []Redirect
+	interwiki string
+}
+
+// err returns an *InvalidTitleError if title was resolved to a page of another
+// wiki, which cannot be looked up here.
+func (p resolvedPage) err(title string) error {
+	if p.interwiki == "" {
+		return nil
+	}
+
+	return &InvalidTitleError{
+		Title:  title,
+		Rule:   RuleInterwiki,
+		Detail: fmt.Sprintf("The title starts with the interwiki prefix '%s:' and names a page of another wiki.", p.interwiki),
+	}
+}
+
+// resolve traces title through the normalizations and redirects of the
+// response and returns the page it ends up on.
+func (r *response) resolve(title string) (resolvedPage, bool) {
+	var resolved resolvedPage
+
+	for _, n := range r.Query.Normalized {
+		if n.From == title {
+			title = n.To
+
+			break
+		}
+	}
+
+	for _, iw := range r.Query.Interwiki {
+		if iw.Title == title {
+			return resolvedPage{interwiki: iw.Prefix}, true
+		}
+	}
+
+	// MediaWiki only follows a single redirect, but guard against longer
+	// chains and loops all the same.
+	visited := map[string]bool{title: true}
+	for {
+		next, ok := r.redirectFrom(title)
+		if !ok || visited[next.To] {
+			break
+		}
+
+		resolved.redirects = append(resolved.redirects, Redirect{
+			From:     next.From,
+			To:       next.To,
+			Fragment: next.ToFragment,
+		})
+		visited[next.To] = true
+		title = next.To
+	}
+
+	for _, p := range r.Query.Pages {
+		if p.Title == title {
+			resolved.page = p
+
+			return resolved, true
+		}
+	}
+
+	return resolved, false
+}
+
+// resolveSingle is resolve for the response to a single-title query. Such a
+// query cannot be ambiguous, so if title cannot be traced through the
+// normalizations and redirects it falls back to the only page returned.
+func (r *response) resolveSingle(title string) (resolvedPage, error) {
+	if resolved, ok := r.resolve(title); ok {
+		return resolved, resolved.err(title)
+	}
+
+	if len(r.Query.Pages) == 0 && len(r.Query.Interwiki) == 1 {
+		resolved := resolvedPage{interwiki: r.Query.Interwiki[0].Prefix}
+
+		return resolvedPage{}, resolved.err(title)
+	}
+
+	if len(r.Query.Pages) != 1 {
+		return resolvedPage{}, fmt.Errorf("%w: no page for the requested title", ErrUnexpectedResponse)
+	}
+
+	return resolvedPage{page: r.Query.Pages[0]}, nil
+}
+
+func (r *response) redirectFrom(title string) (redirect, bool) {
+	for _, rd := range r.Query.Redirects {
+		if rd.From == title {
+			return rd, true
+		}
+	}
+
+	return redirect{}, false
+}
@@ -0,0 +1,115 @@
+package wikipedia
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+type wikidataResponse struct {
+	envelope
+	Entities map[string]wikidataEntity `json:"entities"`
+}
+
+type wikidataEntity struct {
+	Descriptions map[string]wikidataTerm `json:"descriptions"`
+}
+
+type wikidataTerm struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// fillFromWikidata sets the short description of every result to the
+// description of its Wikidata entity, in the language of the client. items
+// holds the Wikidata item ID of each result and may contain empty IDs for
+// articles that are not linked to Wikidata.
+func (c *Client) fillFromWikidata(ctx context.Context, results []*Result, items []string) error {
+	var ids []string
+	seen := make(map[string]bool, len(items))
+	for _, id := range items {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	descriptions := make(map[string]string, len(ids))
+	for start := 0; start < len(ids); start += MaxTitlesPerQuery {
+		end := start + MaxTitlesPerQuery
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		if err := c.wikidataDescriptions(ctx, ids[start:end], descriptions); err != nil {
+			return err
+		}
+	}
+
+	for i, result := range results {
+		if description := descriptions[items[i]]; description != "" {
+			result.ShortDescription = description
+			result.Source = SourceWikidata
+		}
+	}
+
+	return nil
+}
+
+// wikidataDescriptions fetches the descriptions of the given entities in a
+// single query and stores them in descriptions, keyed by entity ID.
+func (c *Client) wikidataDescriptions(ctx context.Context, ids []string, descriptions map[string]string) error {
+	params := url.Values{}
+	params.Set("action", "wbgetentities")
+	params.Set("ids", strings.Join(ids, "|"))
+	params.Set("props", "descriptions")
+	params.Set("languages", c.lang)
+	params.Set("formatversion", "2")
+	params.Set("format", "json")
+
+	var response wikidataResponse
+	err := c.get(ctx, c.wikidataURL, params, &response)
+
+	// An item deleted since the article was linked to it has no
+	// description, like an article that is not linked at all. Wikibase
+	// fails the whole query for it, so the other items are fetched again
+	// without it, or one at a time if the error does not name it.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "no-such-entity" {
+		if len(ids) == 1 {
+			return nil
+		}
+
+		rest := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if id != apiErr.ID {
+				rest = append(rest, id)
+			}
+		}
+
+		if len(rest) < len(ids) {
+			return c.wikidataDescriptions(ctx, rest, descriptions)
+		}
+
+		for _, id := range ids {
+			if err := c.wikidataDescriptions(ctx, []string{id}, descriptions); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for id, entity := range response.Entities {
+		if term, ok := entity.Descriptions[c.lang]; ok {
+			descriptions[id] = term.Value
+		}
+	}
+
+	return nil
+}